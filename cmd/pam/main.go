@@ -3,9 +3,6 @@ package main
 import (
 	"log"
 
-	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/mattn/go-sqlite3"
-
 	"github.com/eduardofuncao/pam/internal/commands/handler"
 	"github.com/eduardofuncao/pam/internal/config"
 )
@@ -12,8 +12,9 @@ import (
 )
 
 func Add(cfg *config.Config) {
-	if len(os.Args) < 3 {
-		log.Fatal("Usage: pam add <query-name> [query]")
+	args, isTemplate := takeFlag(os.Args, "--template")
+	if len(args) < 3 {
+		log.Fatal("Usage: pam add <query-name> [query] [--template]")
 	}
 
 	if cfg.CurrentConnection == "" {
@@ -26,11 +27,11 @@ func Add(cfg *config.Config) {
 	}
 	queries := cfg.Connections[cfg.CurrentConnection].Queries
 
-	queryName := os.Args[2]
+	queryName := args[2]
 	var querySQL string
 
-	if len(os.Args) >= 4 {
-		querySQL = os.Args[3]
+	if len(args) >= 4 {
+		querySQL = args[3]
 	} else {
 		editorCmd := os.Getenv("EDITOR")
 		if editorCmd == "" {
@@ -80,6 +81,8 @@ func Add(cfg *config.Config) {
 		Name: queryName,
 		SQL:  querySQL,
 		Id:   db.GetNextQueryId(queries),
+
+		Template: isTemplate,
 	}
 
 	err := cfg.Save()
@@ -0,0 +1,259 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eduardofuncao/pam/internal/config"
+	"github.com/eduardofuncao/pam/internal/db"
+	"github.com/eduardofuncao/pam/internal/history"
+)
+
+const copyUsage = "Usage: pam copy [--from <conn>] --to <conn> (<query|sql> | --table <name>) [--into <table>] [--create] [--truncate | --upsert [--key <col,...>]] [--batch <rows>] [--continue-on-error] [--param=value ...]"
+
+func Copy(cfg *config.Config) {
+	CopyWithArgs(context.Background(), cfg, os.Args)
+}
+
+// CopyWithArgs reads the rows of a table or query on one connection and
+// inserts them into a table on another, a page at a time, in one transaction
+// on the target. A missing target table is created with --create, its column
+// types mapped from the source engine's to the target's.
+func CopyWithArgs(ctx context.Context, cfg *config.Config, args []string) {
+	args, from, _, err := takeOption(args, "--from")
+	if err != nil {
+		log.Fatal(err)
+	}
+	args, to, _, err := takeOption(args, "--to")
+	if err != nil {
+		log.Fatal(err)
+	}
+	args, tableName, _, err := takeOption(args, "--table", "-t")
+	if err != nil {
+		log.Fatal(err)
+	}
+	args, into, _, err := takeOption(args, "--into")
+	if err != nil {
+		log.Fatal(err)
+	}
+	args, opts, err := loadArgs(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	args, paramValues := splitParamArgs(args)
+
+	source := args[min(2, len(args)):]
+	if tableName != "" {
+		if len(source) > 0 {
+			log.Fatal(copyUsage)
+		}
+		source = []string{tableName}
+	}
+	if to == "" || len(source) == 0 {
+		log.Fatal(copyUsage)
+	}
+	if from == "" {
+		from = cfg.CurrentConnection
+	}
+	for _, name := range []string{from, to} {
+		if _, ok := cfg.Connections[name]; !ok {
+			log.Fatalf("Connection %s does not exist", name)
+		}
+	}
+
+	src := config.FromConnectionYaml(cfg.Connections[from])
+	dst := config.FromConnectionYaml(cfg.Connections[to])
+	stmt, sourceTable, err := exportStatement(src, source, paramValues)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if opts.table = into; opts.table == "" {
+		opts.table = sourceTable
+	}
+	// Two connections to one sqlite file would lock each other out midway
+	if src.GetDialect().ReadsBlockStatements() {
+		if from == to {
+			log.Fatalf("%s can't read and write at once, copy within it with INSERT INTO ... SELECT", from)
+		}
+		if src.GetDbType() == dst.GetDbType() && db.SameSQLiteFile(src.GetConnString(), dst.GetConnString()) {
+			log.Fatalf("%s and %s open the same file, which can't be read and written at once; copy within it with INSERT INTO ... SELECT", from, to)
+		}
+	}
+
+	for _, conn := range []db.DatabaseConnection{src, dst} {
+		if err := conn.Open(); err != nil {
+			log.Fatalf("Could not open the connection to %s/%s: %s", conn.GetDbType(), conn.GetName(), err)
+		}
+	}
+	// A table created for a copied table gets its primary key
+	if sourceTable != "" && opts.create {
+		schema, err := db.DescribeTable(src, sourceTable)
+		if err != nil {
+			log.Fatalf("Could not read the primary key of %s: %v", sourceTable, err)
+		}
+		opts.primaryKey = schema.PrimaryKey
+	}
+
+	ctx, stopSignals := signal.NotifyContext(ctx, os.Interrupt)
+	defer stopSignals()
+	// The source query has a context of its own, which ends with its rows
+	queryCtx, settle, cancel := statementContext(ctx, src, false)
+	defer cancel()
+
+	entry := history.Entry{
+		Time:       time.Now(),
+		Connection: src.GetName(),
+		Query:      stmt.name,
+		SQL:        stmt.sql,
+		Params:     stmt.params,
+		Rows:       -1,
+	}
+	sqlRows, err := src.QueryContext(queryCtx, stmt.text, stmt.args...)
+	if err != nil {
+		entry.Error = queryError(queryCtx, err).Error()
+		recordHistory(cfg, entry)
+		fatalQuery(queryCtx, err)
+	}
+	tableData, err := db.StreamTableData(sqlRows, stmt.sql, src, 0, cancel)
+	if err != nil {
+		entry.Error = queryError(queryCtx, err).Error()
+		recordHistory(cfg, entry)
+		fatalQuery(queryCtx, err)
+	}
+	defer tableData.Close()
+	settle()
+
+	// Without --into a query goes into the table of the same name it reads
+	if opts.table == "" {
+		if opts.table = tableData.TableName; opts.table == "" {
+			log.Fatal("Could not tell which table the query reads, name the target with --into <table>")
+		}
+	}
+
+	kinds := copyKinds(src.GetDialect(), tableData)
+	loader, err := openLoader(ctx, dst, opts, tableData.Columns, func([]string) []string {
+		return kinds
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	stopProgress := copyProgress(loader)
+
+	err = copyRows(loader, kinds, tableData)
+	if err == nil {
+		err = loader.commit()
+	}
+	stopProgress()
+	entry.Duration = time.Since(entry.Time)
+	if err != nil {
+		entry.Error = err.Error()
+		recordHistory(cfg, entry)
+		log.Fatal(loadFailed(ctx, loader, "Copy", err))
+	}
+	entry.Rows = int64(loader.loaded + len(loader.failures))
+	recordHistory(cfg, entry)
+
+	printLoadFailures(loader.failures)
+	fmt.Printf("✓ %s (%.2fs)\n", loader.summary("Copied", to+"/"+opts.table), entry.Duration.Seconds())
+	if len(loader.failures) > 0 {
+		os.Exit(1)
+	}
+}
+
+// copyRows feeds the loader every row of the stream, a page at a time.
+// kinds are those of the source columns.
+func copyRows(loader *tableLoader, kinds []string, tableData *db.TableData) error {
+	read := 0
+	for {
+		for _, row := range tableData.Rows {
+			read++
+			values := make([]any, len(row))
+			for i, cell := range row {
+				values[i] = copyValue(cell.RawValue, kinds[i])
+			}
+			if err := loader.add(loadRow{fmt.Sprintf("row %d", read), values}); err != nil {
+				return err
+			}
+		}
+		if tableData.Stream == nil {
+			return nil
+		}
+		tableData.Rows = nil
+		if err := tableData.Fetch(db.PageSize); err != nil {
+			return err
+		}
+	}
+}
+
+// copyValue readies a scanned value for the target. Drivers scan text, and
+// numbers they don't convert, as bytes, which would be bound as binary, so
+// only the values of binary columns stay bytes.
+func copyValue(v any, kind string) any {
+	b, ok := v.([]byte)
+	if !ok || kind == "bytes" {
+		return v
+	}
+	return string(b)
+}
+
+// copyKinds maps the source columns to ColumnKinds, by their type where the
+// driver names one and else by the values of the first page. They type the
+// columns of a created table and tell which values are binary.
+func copyKinds(d db.Dialect, tableData *db.TableData) []string {
+	kinds := make([]string, len(tableData.Columns))
+	for i := range kinds {
+		if kinds[i] = d.ColumnKind(tableData.ColumnType(i)); kinds[i] != "" {
+			continue
+		}
+		kinds[i] = "string"
+		for _, row := range tableData.Rows {
+			if kind := valueKind(row[i].RawValue); kind != "" {
+				kinds[i] = kind
+				break
+			}
+		}
+	}
+	return kinds
+}
+
+// valueKind tells the kind of a scanned value, "" for NULL.
+func valueKind(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case bool:
+		return "bool"
+	case time.Time:
+		return "timestamp"
+	case []byte:
+		if !utf8.Valid(v) {
+			return "bytes"
+		}
+	}
+	return "string"
+}
+
+// copyProgress keeps a count of the rows copied on stderr while it is a
+// terminal.
+func copyProgress(loader *tableLoader) (stop func()) {
+	if !isTerminal(os.Stderr) {
+		return func() {}
+	}
+	loader.progress = func(loaded int) {
+		fmt.Fprintf(os.Stderr, "\r%d rows copied", loaded)
+	}
+	return func() {
+		if loader.loaded > 0 {
+			fmt.Fprint(os.Stderr, "\r\033[K")
+		}
+	}
+}
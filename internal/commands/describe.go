@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eduardofuncao/pam/internal/config"
+	"github.com/eduardofuncao/pam/internal/db"
+	"github.com/eduardofuncao/pam/internal/table"
+)
+
+var describeColumns = []string{"kind", "name", "type", "nullable", "default", "details"}
+
+func Describe(cfg *config.Config) {
+	DescribeWithArgs(cfg, os.Args, false, nil)
+}
+
+func DescribeWithArgs(cfg *config.Config, args []string, fromTUI bool, cmdExec table.CommandExecutor) (*db.TableData, error) {
+	if len(args) < 3 {
+		if fromTUI {
+			return nil, fmt.Errorf("usage: describe <table-name>")
+		}
+		log.Fatal("Usage: pam describe <table-name>")
+	}
+	tableName := args[2]
+
+	currConn := config.FromConnectionYaml(cfg.Connections[cfg.CurrentConnection])
+	if err := currConn.Open(); err != nil {
+		if fromTUI {
+			return nil, fmt.Errorf("could not open connection: %w", err)
+		}
+		log.Fatalf("Could not open the connection to %s/%s: %s", currConn.GetDbType(), currConn.GetName(), err)
+	}
+
+	start := time.Now()
+	schema, err := db.DescribeTable(currConn, tableName)
+	if err != nil {
+		if fromTUI {
+			return nil, fmt.Errorf("could not describe '%s': %w", tableName, err)
+		}
+		log.Fatalf("Could not describe '%s': %v", tableName, err)
+	}
+
+	tableData := buildDescribeTableData(schema, currConn)
+
+	if !fromTUI {
+		if err := table.RenderWithExecutor(tableData, time.Since(start), cmdExec); err != nil {
+			log.Fatalf("Error rendering table: %v", err)
+		}
+	}
+
+	return tableData, nil
+}
+
+// buildDescribeTableData lists every column with its PK/FK markers, followed
+// by the table's indexes, foreign keys and other constraints.
+func buildDescribeTableData(schema *db.TableSchema, conn db.DatabaseConnection) *db.TableData {
+	var values [][]any
+
+	for _, col := range schema.Columns {
+		nullable := "NO"
+		if col.Nullable {
+			nullable = "YES"
+		}
+		var defaultValue any
+		if col.Default.Valid {
+			defaultValue = col.Default.String
+		}
+
+		var markers []string
+		if schema.IsPrimaryKey(col.Name) {
+			markers = append(markers, "PK")
+		}
+		if fk, ok := schema.ForeignKeyFor(col.Name); ok {
+			markers = append(markers, "FK → "+formatReference(fk))
+		}
+		values = append(values, []any{"column", col.Name, col.Type, nullable, defaultValue, strings.Join(markers, ", ")})
+	}
+
+	for _, idx := range schema.Indexes {
+		kind := "index"
+		switch {
+		case idx.Primary:
+			kind = "primary key"
+		case idx.Unique:
+			kind = "unique index"
+		}
+		values = append(values, []any{kind, idx.Name, "", "", "", "(" + strings.Join(idx.Columns, ", ") + ")"})
+	}
+
+	for _, fk := range schema.ForeignKeys {
+		details := fmt.Sprintf("(%s) → %s", strings.Join(fk.Columns, ", "), formatReference(fk))
+		values = append(values, []any{"foreign key", fk.Name, "", "", "", details})
+	}
+
+	for _, c := range schema.Constraints {
+		kind, details := "check", c.Check
+		if c.Unique {
+			kind, details = "unique", "("+strings.Join(c.Columns, ", ")+")"
+		}
+		values = append(values, []any{kind, c.Name, "", "", "", details})
+	}
+
+	columnTypes := make([]string, len(describeColumns))
+	for i := range columnTypes {
+		columnTypes[i] = "TEXT"
+	}
+	return db.NewTableData(describeColumns, columnTypes, values, conn)
+}
+
+func formatReference(fk db.ForeignKey) string {
+	return fmt.Sprintf("%s(%s)", fk.RefTable, strings.Join(fk.RefColumns, ", "))
+}
@@ -0,0 +1,318 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/eduardofuncao/pam/internal/config"
+	"github.com/eduardofuncao/pam/internal/db"
+	"github.com/eduardofuncao/pam/internal/diff"
+	"github.com/eduardofuncao/pam/internal/history"
+	"github.com/eduardofuncao/pam/internal/table"
+)
+
+const diffUsage = "Usage: pam diff <query|sql|table> (--conn <a> [--conn <b>] | --snapshot) [--key <col,...>] [--all] [--param=value ...]"
+
+// diffMarks color the rows of the diff view by their status.
+var diffMarks = map[diff.Status]table.RowMark{
+	diff.Added:   table.MarkAdded,
+	diff.Removed: table.MarkRemoved,
+	diff.Changed: table.MarkChanged,
+}
+
+// diffSide is one of the two results being compared.
+type diffSide struct {
+	label     string
+	tableData *db.TableData
+}
+
+func Diff(cfg *config.Config) {
+	DiffWithArgs(context.Background(), cfg, os.Args)
+}
+
+// DiffWithArgs runs a query on two connections, or on the current one and
+// against the result it gave the last time it was diffed with --snapshot, and
+// shows the rows that were added, removed or changed. Rows are matched on the
+// --key columns, the table's primary key, or else on all their columns. It
+// exits with 1 when the results differ, like diff(1).
+func DiffWithArgs(ctx context.Context, cfg *config.Config, args []string) {
+	args, conns, err := takeOptions(args, "--conn", "-c")
+	if err != nil {
+		log.Fatal(err)
+	}
+	args, keyList, _, err := takeOption(args, "--key")
+	if err != nil {
+		log.Fatal(err)
+	}
+	args, all := takeFlag(args, "--all")
+	args, snapshot := takeFlag(args, "--snapshot")
+	args, paramValues := splitParamArgs(args)
+
+	source := args[min(2, len(args)):]
+	if len(source) == 0 || len(conns) > 2 || (snapshot && len(conns) > 1) || (!snapshot && len(conns) == 0) {
+		log.Fatal(diffUsage)
+	}
+	var keys []string
+	if keyList != "" {
+		for _, key := range strings.Split(keyList, ",") {
+			keys = append(keys, strings.TrimSpace(key))
+		}
+	}
+	// A single --conn is compared with the current connection
+	if len(conns) == 1 && !snapshot {
+		conns = []string{cfg.CurrentConnection, conns[0]}
+	}
+	if len(conns) == 0 {
+		conns = []string{cfg.CurrentConnection}
+	}
+	for _, name := range conns {
+		if _, ok := cfg.Connections[name]; !ok {
+			log.Fatalf("Connection %s does not exist", name)
+		}
+	}
+	if len(conns) == 2 && conns[0] == conns[1] {
+		log.Fatalf("Both sides run on %s, name another connection with --conn", conns[0])
+	}
+	if snapshot && history.Size(cfg.History.Size) == 0 {
+		log.Fatal("--snapshot keeps results in the history, which is turned off (history.size in the config)")
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	first := config.FromConnectionYaml(cfg.Connections[conns[0]])
+	stmt, sourceTable, err := exportStatement(first, source, paramValues)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := first.Open(); err != nil {
+		log.Fatalf("Could not open the connection to %s/%s: %s", first.GetDbType(), first.GetName(), err)
+	}
+	if keys == nil && sourceTable != "" {
+		if schema, err := db.DescribeTable(first, sourceTable); err == nil {
+			keys = schema.PrimaryKey
+		}
+	}
+
+	var before, after diffSide
+	if snapshot {
+		before, err = lastSnapshot(first, stmt)
+		if err != nil {
+			log.Fatal(err)
+		}
+		after.label = "now"
+		if after.tableData, err = runDiffSide(ctx, cfg, first, stmt, true); err != nil {
+			log.Fatal(err)
+		}
+		if before.tableData == nil {
+			fmt.Printf("✓ Saved a snapshot of %d rows, diff again with --snapshot to compare with it\n", len(after.tableData.Rows))
+			return
+		}
+	} else {
+		second := config.FromConnectionYaml(cfg.Connections[conns[1]])
+		secondStmt, err := diffStatement(second, source, paramValues, stmt)
+		if err != nil {
+			log.Fatalf("%s: %v", second.GetName(), err)
+		}
+		if err := second.Open(); err != nil {
+			log.Fatalf("Could not open the connection to %s/%s: %s", second.GetDbType(), second.GetName(), err)
+		}
+		before.label, after.label = first.GetName(), second.GetName()
+		if before.tableData, err = runDiffSide(ctx, cfg, first, stmt, false); err != nil {
+			log.Fatalf("%s: %v", first.GetName(), err)
+		}
+		if after.tableData, err = runDiffSide(ctx, cfg, second, secondStmt, false); err != nil {
+			log.Fatalf("%s: %v", second.GetName(), err)
+		}
+	}
+
+	result, err := diff.Compare(before.tableData, after.tableData, keys)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !result.Differs() {
+		fmt.Printf("✓ No differences (%d rows)\n", len(result.Rows))
+		return
+	}
+
+	status := fmt.Sprintf("%s → %s: %s", before.label, after.label, result.Summary())
+	viewData, marks := diffTable(result, all)
+	if isTerminal(os.Stdout) {
+		if err := table.ShowMarked(viewData, marks, status); err != nil {
+			log.Fatalf("Error rendering diff: %v", err)
+		}
+	} else {
+		printDiff(viewData)
+		fmt.Fprintln(os.Stderr, status)
+	}
+	os.Exit(1)
+}
+
+// diffStatement resolves the source on the second connection. A saved query
+// only the first connection has runs there as written.
+func diffStatement(conn db.DatabaseConnection, source []string, paramValues map[string]string, first statement) (statement, error) {
+	if _, found := db.FindQueryWithSelector(conn.GetQueries(), source[0]); !found && first.name != "" {
+		sqlText, sqlArgs, used, err := bindQuery(conn, db.Query{Name: first.name, SQL: first.sql}, paramValues, false)
+		if err != nil {
+			return statement{}, err
+		}
+		return statement{name: first.name, sql: first.sql, text: sqlText, args: sqlArgs, params: used}, nil
+	}
+	stmt, _, err := exportStatement(conn, source, paramValues)
+	return stmt, err
+}
+
+// runDiffSide reads every row of a statement and records the run, keeping
+// the rows as a snapshot when asked to.
+func runDiffSide(ctx context.Context, cfg *config.Config, conn db.DatabaseConnection, stmt statement, keep bool) (*db.TableData, error) {
+	queryCtx, settle, cancel := statementContext(ctx, conn, false)
+	defer cancel()
+
+	entry := history.Entry{
+		Time:       time.Now(),
+		Connection: conn.GetName(),
+		Query:      stmt.name,
+		SQL:        stmt.sql,
+		Params:     stmt.params,
+		Rows:       -1,
+	}
+	fail := func(err error) (*db.TableData, error) {
+		entry.Duration = time.Since(entry.Time)
+		entry.Error = queryError(queryCtx, err).Error()
+		recordHistory(cfg, entry)
+		return nil, queryError(queryCtx, err)
+	}
+
+	sqlRows, err := conn.QueryContext(queryCtx, stmt.text, stmt.args...)
+	if err != nil {
+		return fail(err)
+	}
+	tableData, err := db.StreamTableData(sqlRows, stmt.sql, conn, 0, cancel)
+	if err != nil {
+		return fail(err)
+	}
+	defer tableData.Close()
+	settle()
+	if err := tableData.Fetch(0); err != nil {
+		return fail(err)
+	}
+	if tableData.Exec != nil {
+		return nil, fmt.Errorf("the statement returned no rows to compare")
+	}
+	entry.Duration = time.Since(entry.Time)
+	entry.Rows = int64(len(tableData.Rows))
+
+	if keep {
+		if entry.Snapshot, err = history.SaveSnapshot(config.HistoryFile, snapshotOf(tableData)); err != nil {
+			return nil, fmt.Errorf("could not save the snapshot: %w", err)
+		}
+	}
+	recordHistory(cfg, entry)
+	return tableData, nil
+}
+
+// lastSnapshot finds the newest snapshot of the same statement, with the same
+// parameter values, on the same connection, skipping snapshots whose file is
+// gone. Its table data is nil when there is none yet.
+func lastSnapshot(conn db.DatabaseConnection, stmt statement) (diffSide, error) {
+	entries, err := history.Load(config.HistoryFile)
+	if err != nil {
+		return diffSide{}, fmt.Errorf("could not read history: %w", err)
+	}
+	for _, entry := range slices.Backward(entries) {
+		if entry.Snapshot == "" || entry.Connection != conn.GetName() || entry.SQL != stmt.sql || !maps.Equal(entry.Params, stmt.params) {
+			continue
+		}
+		snapshot, err := history.LoadSnapshot(config.HistoryFile, entry.Snapshot)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return diffSide{}, fmt.Errorf("could not read the snapshot of %s: %w", entry.Time.Local().Format("2006-01-02 15:04:05"), err)
+		}
+		values := make([][]any, len(snapshot.Rows))
+		for i, row := range snapshot.Rows {
+			values[i] = make([]any, len(row))
+			for j, v := range row {
+				if v != nil {
+					values[i][j] = *v
+				}
+			}
+		}
+		label := "snapshot of " + entry.Time.Local().Format("2006-01-02 15:04:05")
+		return diffSide{label, db.NewTableData(snapshot.Columns, snapshot.Types, values, conn)}, nil
+	}
+	return diffSide{}, nil
+}
+
+// snapshotOf keeps the values of a result as diffs compare them.
+func snapshotOf(tableData *db.TableData) history.Snapshot {
+	snapshot := history.Snapshot{
+		Columns: tableData.Columns,
+		Types:   tableData.ColumnTypes,
+		Rows:    make([][]*string, len(tableData.Rows)),
+	}
+	for i, row := range tableData.Rows {
+		snapshot.Rows[i] = make([]*string, len(row))
+		for j, cell := range row {
+			if cell.RawValue != nil {
+				text := diff.CellText(cell)
+				snapshot.Rows[i][j] = &text
+			}
+		}
+	}
+	return snapshot
+}
+
+// diffTable lays out a comparison for the table view: a leading column with
+// the status symbol, and changed cells showing "old → new", binary values in
+// hex. Unchanged rows are left out unless all is set.
+func diffTable(result *diff.Result, all bool) (*db.TableData, table.Marks) {
+	columns := append([]string{"±"}, result.Columns...)
+	var values [][]any
+	var marks table.Marks
+	for _, row := range result.Rows {
+		if row.Status == diff.Same && !all {
+			continue
+		}
+		rowValues := []any{row.Status.Symbol()}
+		changed := []bool{false}
+		for i, cell := range row.Values {
+			var v any
+			if cell.RawValue != nil {
+				v = cell.Value
+			}
+			if row.Status == diff.Changed && row.Changed[i] {
+				v = diff.CellText(row.Old[i]) + " → " + diff.CellText(cell)
+			}
+			rowValues = append(rowValues, v)
+			changed = append(changed, row.Status == diff.Changed && row.Changed[i])
+		}
+		values = append(values, rowValues)
+		marks.Rows = append(marks.Rows, diffMarks[row.Status])
+		marks.Changed = append(marks.Changed, changed)
+	}
+	return db.NewTableData(columns, nil, values, nil), marks
+}
+
+// printDiff writes the diff as tab separated lines, for when stdout is not a
+// terminal.
+func printDiff(tableData *db.TableData) {
+	fmt.Println(strings.Join(tableData.Columns, "\t"))
+	for _, row := range tableData.Rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = cell.Value
+		}
+		fmt.Println(strings.Join(cells, "\t"))
+	}
+}
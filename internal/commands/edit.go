@@ -102,6 +102,16 @@ func editQueries(cfg *config.Config, editorCmd string) {
 		log.Fatalf("Failed to parse edited queries: %v", err)
 	}
 
+	// Parameter declarations and the template flag aren't part of the
+	// edited text; keep them
+	for name, query := range editedQueries {
+		if previous, ok := conn.Queries[name]; ok {
+			query.Params = previous.Params
+			query.Template = previous.Template
+			editedQueries[name] = query
+		}
+	}
+
 	conn.Queries = editedQueries
 	cfg.Connections[cfg.CurrentConnection] = conn
 
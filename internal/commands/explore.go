@@ -1,7 +1,7 @@
 package commands
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -10,19 +10,29 @@ import (
 
 	"github.com/eduardofuncao/pam/internal/config"
 	"github.com/eduardofuncao/pam/internal/db"
-	"github.com/eduardofuncao/pam/internal/spinner"
 	"github.com/eduardofuncao/pam/internal/table"
 )
 
 func Explore(cfg *config.Config) {
-	ExploreWithArgs(cfg, os.Args, false, nil)
+	ExploreWithArgs(context.Background(), cfg, os.Args, false, nil)
 }
 
 func ExploreWithExecutor(cfg *config.Config, cmdExec table.CommandExecutor) {
-	ExploreWithArgs(cfg, os.Args, false, cmdExec)
+	ExploreWithArgs(context.Background(), cfg, os.Args, false, cmdExec)
 }
 
-func ExploreWithArgs(cfg *config.Config, args []string, fromTUI bool, cmdExec table.CommandExecutor) (*db.TableData, error) {
+func ExploreWithArgs(ctx context.Context, cfg *config.Config, args []string, fromTUI bool, cmdExec table.CommandExecutor) (*db.TableData, error) {
+	args, output, _, err := takeOption(args, "--format")
+	if err == nil {
+		output, err = outputFormat(output, fromTUI)
+	}
+	if err != nil {
+		if fromTUI {
+			return nil, err
+		}
+		log.Fatal(err)
+	}
+
 	if len(args) < 3 {
 		if fromTUI {
 			return nil, fmt.Errorf("usage: explore <table-name> [--limit|-l <number>]")
@@ -33,14 +43,18 @@ func ExploreWithArgs(cfg *config.Config, args []string, fromTUI bool, cmdExec ta
 	}
 
 	tableName := args[2]
+	currConn := config.FromConnectionYaml(cfg.Connections[cfg.CurrentConnection])
 	limit := 1000
+	if rowLimit := currConn.GetLimits().RowLimit; rowLimit > 0 {
+		limit = rowLimit
+	}
 
 	if len(args) > 3 {
 		for i := 3; i < len(args); i++ {
 			if args[i] == "--limit" || args[i] == "-l" {
 				if i+1 < len(args) {
 					parsedLimit, err := strconv.Atoi(args[i+1])
-					if err != nil {
+					if err != nil || parsedLimit < 1 {
 						if fromTUI {
 							return nil, fmt.Errorf("invalid limit value: %s", args[i+1])
 						}
@@ -53,8 +67,6 @@ func ExploreWithArgs(cfg *config.Config, args []string, fromTUI bool, cmdExec ta
 		}
 	}
 
-	currConn := config.FromConnectionYaml(cfg.Connections[cfg.CurrentConnection])
-
 	if err := currConn.Open(); err != nil {
 		if fromTUI {
 			return nil, fmt.Errorf("could not open connection: %w", err)
@@ -62,19 +74,36 @@ func ExploreWithArgs(cfg *config.Config, args []string, fromTUI bool, cmdExec ta
 		log.Fatalf("Could not open the connection to %s/%s: %s", currConn.GetDbType(), currConn.GetName(), err)
 	}
 
-	querySQL := fmt.Sprintf("SELECT * FROM %s LIMIT %d", tableName, limit)
+	querySQL, err := db.SelectAllSQL(currConn.GetDialect(), tableName, limit)
+	if err != nil {
+		if fromTUI {
+			return nil, err
+		}
+		log.Fatalf("Could not explore '%s': %v", tableName, err)
+	}
+
+	ctx, settle, cancel := statementContext(ctx, currConn, fromTUI)
+	streamed := false
+	defer func() {
+		// A streamed result releases the statement once its rows are done
+		if !streamed {
+			cancel()
+		}
+	}()
 
 	start := time.Now()
-	var done chan struct{}
-	if !fromTUI {
-		done = make(chan struct{})
-		go spinner.Wait(done)
-	}
+	stopSpinner := startSpinner(!fromTUI)
 
-	rows, err := currConn.QueryDirect(querySQL)
+	// One extra row tells whether the table has more than limit rows
+	probeSQL, _ := db.SelectAllSQL(currConn.GetDialect(), tableName, limit+1)
+	sqlRows, err := currConn.QueryContext(ctx, probeSQL)
 	if err != nil {
-		if !fromTUI {
-			done <- struct{}{}
+		stopSpinner()
+		if ctx.Err() != nil {
+			if fromTUI {
+				return nil, queryError(ctx, err)
+			}
+			fatalQuery(ctx, err)
 		}
 		if fromTUI {
 			return nil, fmt.Errorf("could not query table '%s': %v", tableName, err)
@@ -82,32 +111,21 @@ func ExploreWithArgs(cfg *config.Config, args []string, fromTUI bool, cmdExec ta
 		log.Fatalf("Could not query table '%s': %v", tableName, err)
 	}
 
-	sqlRows, ok := rows.(*sql.Rows)
-	if !ok {
-		if !fromTUI {
-			done <- struct{}{}
-		}
-		if fromTUI {
-			return nil, fmt.Errorf("query did not return *sql.Rows")
-		}
-		log.Fatal("Query did not return *sql.Rows")
-	}
-
-	tableData, err := db.BuildTableData(sqlRows, querySQL, currConn)
+	tableData, err := db.StreamTableData(sqlRows, querySQL, currConn, limit, cancel)
 	if err != nil {
-		if !fromTUI {
-			done <- struct{}{}
-		}
+		stopSpinner()
 		if fromTUI {
-			return nil, fmt.Errorf("error building table data: %w", err)
+			return nil, queryError(ctx, err)
 		}
-		log.Fatalf("Error building table data: %v", err)
+		fatalQuery(ctx, err)
 	}
+	streamed = true
+	settle()
 
 	if !fromTUI {
-		done <- struct{}{}
+		stopSpinner()
 		elapsed := time.Since(start)
-		if err := table.RenderWithExecutor(tableData, elapsed, cmdExec); err != nil {
+		if _, err := showRows(tableData, elapsed, output, cmdExec); err != nil {
 			log.Fatalf("Error rendering table: %v", err)
 		}
 	}
@@ -0,0 +1,259 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eduardofuncao/pam/internal/config"
+	"github.com/eduardofuncao/pam/internal/db"
+	"github.com/eduardofuncao/pam/internal/format"
+	"github.com/eduardofuncao/pam/internal/history"
+)
+
+const exportUsage = "Usage: pam export <query|table|sql> -o <file> [--format <format>] [--no-header] [--delimiter <char>] [--null <text>] [--json-strings] [--table <name>] [--param=value ...]"
+
+func Export(cfg *config.Config) {
+	ExportWithArgs(context.Background(), cfg, os.Args)
+}
+
+// ExportWithArgs writes the rows of a saved query, a table or raw SQL to a
+// file in the format its extension names. Rows are read a page at a time and
+// written as they come, ignoring row_limit, so exports of any size run in
+// constant memory. An export that fails or is cancelled leaves the file as it
+// was.
+func ExportWithArgs(ctx context.Context, cfg *config.Config, args []string) {
+	path, output, opts, args, err := exportArgs(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	args, paramValues := splitParamArgs(args)
+	if len(args) < 3 {
+		log.Fatal(exportUsage)
+	}
+
+	conn := config.FromConnectionYaml(cfg.Connections[cfg.CurrentConnection])
+	stmt, tableName, err := exportStatement(conn, args[2:], paramValues)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if opts.Table == "" {
+		opts.Table = tableName
+	}
+
+	if err := conn.Open(); err != nil {
+		log.Fatalf("Could not open the connection to %s/%s: %s", conn.GetDbType(), conn.GetName(), err)
+	}
+
+	// Ctrl+C keeps cancelling the export once rows are coming in
+	ctx, stopSignals := signal.NotifyContext(ctx, os.Interrupt)
+	defer stopSignals()
+	queryCtx, settle, cancel := statementContext(ctx, conn, false)
+	defer cancel()
+
+	entry := history.Entry{
+		Time:       time.Now(),
+		Connection: conn.GetName(),
+		Query:      stmt.name,
+		SQL:        stmt.sql,
+		Params:     stmt.params,
+		Rows:       -1,
+	}
+	stopSpinner := startSpinner(path != "-")
+	fail := func(err error, rows int) {
+		stopSpinner()
+		if queryCtx.Err() != nil {
+			err = queryError(queryCtx, err)
+		}
+		entry.Duration = time.Since(entry.Time)
+		entry.Rows = int64(rows)
+		entry.Error = err.Error()
+		recordHistory(cfg, entry)
+		log.Fatalf("Export failed: %v", err)
+	}
+
+	sqlRows, err := conn.QueryContext(queryCtx, stmt.text, stmt.args...)
+	if err != nil {
+		fail(err, -1)
+	}
+	// The context outlives the stream, so a cancelled one means Ctrl+C or
+	// the timeout rather than a failed read
+	tableData, err := db.StreamTableData(sqlRows, stmt.sql, conn, 0, nil)
+	if err != nil {
+		fail(err, -1)
+	}
+	defer tableData.Close()
+	settle()
+
+	out, commit, discard, err := createExportFile(path)
+	if err != nil {
+		fail(err, -1)
+	}
+	rows, err := format.Write(out, output, tableData, opts)
+	if err == nil {
+		err = commit()
+	}
+	if err != nil {
+		discard()
+		fail(err, rows)
+	}
+	stopSpinner()
+
+	entry.Duration = time.Since(entry.Time)
+	entry.Rows = int64(rows)
+	recordHistory(cfg, entry)
+
+	summary := fmt.Sprintf("✓ Exported %d rows to %s as %s (%.2fs)", rows, path, output, entry.Duration.Seconds())
+	if path == "-" {
+		fmt.Fprintln(os.Stderr, summary)
+	} else {
+		fmt.Println(summary)
+	}
+}
+
+// exportArgs takes the export options out of args and returns the file, its
+// format and how to write it, with the remaining args.
+func exportArgs(args []string) (path, output string, opts format.Options, rest []string, err error) {
+	args, path, _, err = takeOption(args, "-o", "--output")
+	if err != nil {
+		return
+	}
+	if path == "" {
+		err = fmt.Errorf("%s", exportUsage)
+		return
+	}
+
+	args, output, _, err = takeOption(args, "--format")
+	if err != nil {
+		return
+	}
+	if output == "" {
+		if path == "-" {
+			output = format.CSV
+		} else if output, err = format.FromExtension(path); err != nil {
+			return
+		}
+	}
+	if err = format.Check(output); err != nil {
+		return
+	}
+	opts = format.Defaults(output)
+
+	args, delimiter, hasDelimiter, err := takeOption(args, "--delimiter", "-d")
+	if err != nil {
+		return
+	}
+	if hasDelimiter {
+		if opts.Delimiter, err = exportDelimiter(delimiter); err != nil {
+			return
+		}
+	}
+	args, null, hasNull, err := takeOption(args, "--null")
+	if err != nil {
+		return
+	}
+	if hasNull {
+		opts.Null = null
+	}
+	if args, opts.Table, _, err = takeOption(args, "--table"); err != nil {
+		return
+	}
+
+	var noHeader bool
+	args, noHeader = takeFlag(args, "--no-header")
+	opts.Header = !noHeader
+	args, opts.Strings = takeFlag(args, "--json-strings")
+	return path, output, opts, args, nil
+}
+
+// exportDelimiter reads a one character delimiter; "tab" and \t name a tab.
+func exportDelimiter(s string) (rune, error) {
+	if s == "tab" || s == `\t` {
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 || size != len(s) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, fmt.Errorf("invalid delimiter %q: use a single character other than a quote or newline", s)
+	}
+	return r, nil
+}
+
+// exportStatement works out what to export: a saved query (rendered and with
+// its parameters bound), raw SQL, or else a whole table, whose name is
+// returned for sql-insert.
+func exportStatement(conn db.DatabaseConnection, source []string, paramValues map[string]string) (statement, string, error) {
+	if query, found := db.FindQueryWithSelector(conn.GetQueries(), source[0]); found && len(source) == 1 {
+		rendered, err := db.RenderQuery(conn, query)
+		if err != nil {
+			return statement{}, "", err
+		}
+		query.SQL = rendered
+		sqlText, sqlArgs, used, err := bindQuery(conn, query, paramValues, false)
+		if err != nil {
+			return statement{}, "", err
+		}
+		return statement{name: query.Name, sql: query.SQL, text: sqlText, args: sqlArgs, params: used}, "", nil
+	}
+
+	rawSQL := strings.Join(source, " ")
+	if looksLikeSQL(rawSQL) {
+		sqlText, sqlArgs, used, err := bindQuery(conn, db.Query{SQL: rawSQL}, paramValues, false)
+		if err != nil {
+			return statement{}, "", err
+		}
+		return statement{sql: rawSQL, text: sqlText, args: sqlArgs, params: used}, "", nil
+	}
+
+	if len(source) > 1 {
+		return statement{}, "", fmt.Errorf("could not find query or table %q", rawSQL)
+	}
+	if len(paramValues) > 0 {
+		return statement{}, "", fmt.Errorf("a table takes no parameters")
+	}
+	quoted, err := db.QuoteName(conn.GetDialect(), source[0])
+	if err != nil {
+		return statement{}, "", fmt.Errorf("could not find query or table %q: %w", source[0], err)
+	}
+	sql := "SELECT * FROM " + quoted
+	return statement{sql: sql, text: sql}, source[0], nil
+}
+
+// createExportFile opens the export target, "-" being stdout. A file is
+// written aside and only replaces the target on commit, so an existing file
+// survives a failed export; discard drops what was written.
+func createExportFile(path string) (out io.Writer, commit func() error, discard func(), err error) {
+	if path == "-" {
+		return os.Stdout, func() error { return nil }, func() {}, nil
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	commit = func() error {
+		// Temporary files are private; the export gets the target's mode
+		mode := os.FileMode(0644)
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		err := f.Chmod(mode)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(f.Name(), path)
+		}
+		return err
+	}
+	discard = func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	return f, commit, discard, nil
+}
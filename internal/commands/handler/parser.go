@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -11,10 +12,10 @@ import (
 )
 
 func Parse(cfg *config.Config) {
-	ParseWithArgs(cfg, os.Args, false)
+	ParseWithArgs(context.Background(), cfg, os.Args, false)
 }
 
-func ParseWithArgs(cfg *config.Config, args []string, fromTUI bool) (*db.TableData, error) {
+func ParseWithArgs(ctx context.Context, cfg *config.Config, args []string, fromTUI bool) (*db.TableData, error) {
 	if len(args) < 2 {
 		if fromTUI {
 			return nil, fmt.Errorf("no command provided")
@@ -55,10 +56,10 @@ func ParseWithArgs(cfg *config.Config, args []string, fromTUI bool) (*db.TableDa
 		}
 		commands.Remove(cfg)
 	case "run", "query":
-		cmdExec := func(args []string) (*db.TableData, error) {
-			return ParseWithArgs(cfg, args, true)
+		cmdExec := func(ctx context.Context, args []string) (*db.TableData, error) {
+			return ParseWithArgs(ctx, cfg, args, true)
 		}
-		return commands.RunWithArgs(cfg, args, fromTUI, cmdExec)
+		return commands.RunWithArgs(ctx, cfg, args, fromTUI, cmdExec)
 	case "list", "ls":
 		if fromTUI {
 			return nil, fmt.Errorf("list command not available in TUI")
@@ -78,12 +79,40 @@ func ParseWithArgs(cfg *config.Config, args []string, fromTUI bool) (*db.TableDa
 		if fromTUI {
 			return nil, fmt.Errorf("history command not available in TUI")
 		}
-		commands.History(cfg)
+		cmdExec := func(ctx context.Context, args []string) (*db.TableData, error) {
+			return ParseWithArgs(ctx, cfg, args, true)
+		}
+		commands.HistoryWithArgs(ctx, cfg, args, cmdExec)
+	case "export":
+		if fromTUI {
+			return nil, fmt.Errorf("export command not available in TUI")
+		}
+		commands.ExportWithArgs(ctx, cfg, args)
+	case "import":
+		if fromTUI {
+			return nil, fmt.Errorf("import command not available in TUI")
+		}
+		commands.ImportWithArgs(ctx, cfg, args)
+	case "copy":
+		if fromTUI {
+			return nil, fmt.Errorf("copy command not available in TUI")
+		}
+		commands.CopyWithArgs(ctx, cfg, args)
+	case "diff":
+		if fromTUI {
+			return nil, fmt.Errorf("diff command not available in TUI")
+		}
+		commands.DiffWithArgs(ctx, cfg, args)
 	case "explore":
-		cmdExec := func(args []string) (*db.TableData, error) {
-			return ParseWithArgs(cfg, args, true)
+		cmdExec := func(ctx context.Context, args []string) (*db.TableData, error) {
+			return ParseWithArgs(ctx, cfg, args, true)
+		}
+		return commands.ExploreWithArgs(ctx, cfg, args, fromTUI, cmdExec)
+	case "describe", "desc":
+		cmdExec := func(ctx context.Context, args []string) (*db.TableData, error) {
+			return ParseWithArgs(ctx, cfg, args, true)
 		}
-		return commands.ExploreWithArgs(cfg, args, fromTUI, cmdExec)
+		return commands.DescribeWithArgs(cfg, args, fromTUI, cmdExec)
 	default:
 		if fromTUI {
 			return nil, fmt.Errorf("unknown command: %s", command)
@@ -31,14 +31,40 @@ func printMainHelp() {
 
 	gohelp.PrintHeader("Queries")
 	gohelp.Item("add <name> [sql]", "Save a query")
+	gohelp.Item("add <name> --template", "Save a template query (see help connections)")
 	gohelp.Item("remove <name>", "Delete a query")
 	gohelp.Item("run <name>", "Execute saved query")
 	gohelp.Item("run <name> -e", "Execute with editor")
 	gohelp.Item("run '<sql>'", "Execute raw SQL")
+	gohelp.Item("run <name> --id=42", "Bind :id in the query (missing values are asked for)")
+	gohelp.Item("run <name> --show-sql", "Print the rendered SQL before it runs")
+	gohelp.Item("run <name> --format csv", "Rows as table|csv|tsv|json|ndjson|markdown|sql-insert (tsv when piped)")
+	gohelp.Item("run -f <file.sql>", "Execute a script, one statement after another")
+	gohelp.Item("run -", "Execute a script read from stdin")
+	gohelp.Item("  --continue-on-error", "Keep going when a statement fails")
+	gohelp.Item("  --transaction", "Run the script in one transaction")
 
 	gohelp.PrintHeader("Browse")
-	gohelp.Item("list [queries|connections]", "List items")
+	gohelp.Item("list [queries|connections|tables|views|schemas]", "List items")
 	gohelp.Item("explore [table]", "Browse tables/data")
+	gohelp.Item("explore <table> --format csv", "Write the table out instead of browsing it")
+	gohelp.Item("describe <table>", "Show columns, keys, indexes and constraints")
+	gohelp.Item("export <query|table|sql> -o <file>", "Write rows to .csv .tsv .json .ndjson .sql .xlsx .md")
+	gohelp.Item("  --no-header / --delimiter ';'", "CSV and TSV header and separator")
+	gohelp.Item("  --null '' / --json-strings", "NULL text in CSV/TSV, JSON values as strings")
+	gohelp.Item("  --table <name> / --format <fmt>", "INSERT target, format when the extension won't do")
+	gohelp.Item("import <file> [--table <name>]", "Load .csv .tsv .json .ndjson into a table (named after the file)")
+	gohelp.Item("  --truncate / --upsert [--key <cols>]", "Empty the table first, or update rows whose key exists")
+	gohelp.Item("  --create", "Create a missing table with types guessed from the data")
+	gohelp.Item("  --continue-on-error", "Commit the rows that load and list the ones that fail")
+	gohelp.Item("copy --to <conn> <query|sql>", "Copy rows into a table of another connection")
+	gohelp.Item("  --from <conn> / --table <name>", "Source connection (current one) and table")
+	gohelp.Item("  --into <table>", "Target table, the source table's name by default")
+	gohelp.Item("  --create / --truncate / --upsert", "As for import; created columns get mapped types")
+	gohelp.Item("diff <query|sql|table> --conn <a> [--conn <b>]", "Compare rows across connections; one --conn compares with the current")
+	gohelp.Item("diff <query|sql|table> --snapshot", "Compare with the result the last --snapshot saved")
+	gohelp.Item("  --key <cols> / --all", "Match rows on columns (primary key, else whole row), show same rows")
+	gohelp.Item("history [--conn <name>] [--status ok|error]", "Past runs: Enter runs again, a saves as query")
 	gohelp.Item("conf", "Edit config in $EDITOR")
 
 	printAvailablePages()
@@ -55,7 +81,7 @@ func printAvailablePages() {
 func printTUIHelp() {
 	gohelp.PrintHeader("Navigation")
 	gohelp.Item("hjkl / Arrows", "Move cursor")
-	gohelp.Item("g / G", "First / last row")
+	gohelp.Item("g / G", "First / last loaded row (more load as you scroll)")
 	gohelp.Item("0 / $", "First / last column")
 	gohelp.Item("Ctrl+U / Ctrl+D", "Page up / down")
 	gohelp.Item("q", "Quit")
@@ -64,13 +90,21 @@ func printTUIHelp() {
 	gohelp.Item("v", "Toggle visual selection")
 	gohelp.Item("y", "Yank cell or selection")
 	gohelp.Item("e", "Edit cell (opens $EDITOR)")
+	gohelp.Item("E / T", "Edit selection in $EDITOR as CSV / TSV (\\N is NULL)")
 	gohelp.Item("d", "Clear cell to NULL (with confirm)")
+	gohelp.Item("D", "Delete row or selected rows (with confirm)")
+	gohelp.Item("o / O", "Insert row below / above (form)")
+	gohelp.Item("u / Ctrl+R", "Undo / redo cell edit")
+	gohelp.Item("s", "Toggle staging (queue changes)")
+	gohelp.Item("w", "Review staged SQL: c commit, x discard")
 
 	gohelp.PrintHeader("Command Prompt")
 	fmt.Println("  Press ; to open the command prompt")
 	fmt.Println()
 	gohelp.Item("Esc", "Cancel prompt")
 	gohelp.Item("Enter", "Execute command")
+	gohelp.Item("Ctrl+C / Esc", "Cancel a running command")
+	gohelp.Item("describe [table]", "Structure of current/given table")
 
 	gohelp.PrintHeader("SQL Expansion")
 	fmt.Println("  Commands auto-expand using current table:")
@@ -88,6 +122,8 @@ func printConnectionsHelp() {
 	gohelp.PrintHeader("SQLite")
 	fmt.Println("  pam init mydb sqlite3 ./data.db")
 	fmt.Println("  pam init mydb sqlite3 /absolute/path/to/db.sqlite")
+	fmt.Println("  pam init mydb sqlite3 \"file:./data.db?mode=ro\"")
+	fmt.Println("  pam init scratch sqlite3 :memory:")
 
 	gohelp.PrintHeader("PostgreSQL")
 	fmt.Println("  pam init mydb postgres \"host=localhost port=5432 dbname=mydb sslmode=disable\"")
@@ -96,10 +132,23 @@ func printConnectionsHelp() {
 	gohelp.PrintHeader("MySQL")
 	fmt.Println("  pam init mydb mysql \"user:pass@tcp(localhost:3306)/dbname\"")
 	fmt.Println("  pam init mydb mysql \"user:pass@unix(/var/run/mysqld/mysqld.sock)/dbname\"")
+	fmt.Println("  pam init mydb mariadb \"user:pass@tcp(localhost:3306)/dbname?parseTime=true&charset=utf8mb4\"")
 
 	gohelp.PrintHeader("Oracle")
 	fmt.Println("  pam init mydb oracle \"user/pass@localhost:1521/service\"")
 	fmt.Println("  pam init mydb oracle 'user/pass@(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=localhost)(PORT=1521))(CONNECT_DATA=(SERVICE_NAME=orcl)))'")
+
+	gohelp.PrintHeader("Limits (per connection, set with pam conf)")
+	gohelp.Item("query_timeout: 30s", "Cancel statements running longer")
+	gohelp.Item("connect_timeout: 5s", "Fail when the server doesn't answer in time")
+	gohelp.Item("row_limit: 10000", "Fetch at most this many rows, flag the rest as truncated")
+
+	gohelp.PrintHeader("Template queries (template: true, Go text/template)")
+	gohelp.Item("vars: {tenant: acme}", "Per-connection variables")
+	gohelp.Item("{{ .Vars.tenant }}", "A variable of the connection")
+	gohelp.Item("{{ env \"USER\" \"me\" }}", "An environment variable, with an optional fallback")
+	gohelp.Item("{{ include \"last_week\" }}", "Another saved query as a partial")
+	gohelp.Item("{{ .Connection }} / {{ .DBType }}", "Connection name / engine")
 	fmt.Println()
 
 	gohelp.Separator()
@@ -1,11 +1,206 @@
 package commands
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/eduardofuncao/pam/internal/config"
+	"github.com/eduardofuncao/pam/internal/db"
+	"github.com/eduardofuncao/pam/internal/history"
+	"github.com/eduardofuncao/pam/internal/table"
 )
 
+var historyColumns = []string{"#", "when", "connection", "query", "status", "rows", "time", "sql", "error"}
+
+var historyKeys = []table.PickKey{
+	{Key: "enter", Hint: "run"},
+	{Key: "a", Hint: "save as query"},
+}
+
+// historyFilter narrows `pam history` down to a connection and a status.
+type historyFilter struct {
+	connection string
+	status     string // "ok" or "error"
+}
+
+func (f historyFilter) matches(e history.Entry) bool {
+	if f.connection != "" && e.Connection != f.connection {
+		return false
+	}
+	switch f.status {
+	case "ok":
+		return !e.Failed()
+	case "error":
+		return e.Failed()
+	}
+	return true
+}
+
 func History(cfg *config.Config) {
-	fmt.Println("Under construction...")
+	HistoryWithArgs(context.Background(), cfg, os.Args, nil)
+}
+
+// HistoryWithArgs lists the statements run so far, newest first, in the table
+// view. Enter runs the selected one again on its connection and a saves it
+// as a query.
+func HistoryWithArgs(ctx context.Context, cfg *config.Config, args []string, cmdExec table.CommandExecutor) {
+	filter, err := historyArgs(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	all, err := history.Load(config.HistoryFile)
+	if err != nil {
+		log.Fatalf("Could not read history: %v", err)
+	}
+	var entries []history.Entry
+	for _, entry := range slices.Backward(all) {
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		if len(all) == 0 {
+			fmt.Println("No queries run yet")
+		} else {
+			fmt.Println("No history entries match")
+		}
+		return
+	}
+
+	key, row, err := table.Pick(historyTable(entries), historyKeys)
+	if err != nil {
+		log.Fatalf("Error rendering history: %v", err)
+	}
+	switch key {
+	case "enter":
+		rerunHistory(ctx, cfg, entries[row], cmdExec)
+	case "a":
+		saveHistoryQuery(cfg, entries[row])
+	}
+}
+
+// historyArgs reads --conn <name> and --status ok|error.
+func historyArgs(args []string) (historyFilter, error) {
+	var filter historyFilter
+	for i := 2; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		if !hasValue {
+			if i+1 >= len(args) {
+				return filter, fmt.Errorf("usage: pam history [--conn <name>] [--status ok|error]")
+			}
+			value = args[i+1]
+			i++
+		}
+		switch flag {
+		case "--conn", "--connection", "-c":
+			filter.connection = value
+		case "--status", "-s":
+			if value != "ok" && value != "error" {
+				return filter, fmt.Errorf("--status takes ok or error, got %q", value)
+			}
+			filter.status = value
+		default:
+			return filter, fmt.Errorf("unknown flag %s (use --conn <name> or --status ok|error)", flag)
+		}
+	}
+	return filter, nil
+}
+
+func historyTable(entries []history.Entry) *db.TableData {
+	values := make([][]any, len(entries))
+	for i, e := range entries {
+		status, rows := "ok", ""
+		if e.Failed() {
+			status = "error"
+		}
+		if e.Rows >= 0 {
+			rows = fmt.Sprint(e.Rows)
+			if e.MoreRows {
+				rows += "+"
+			}
+		}
+		values[i] = []any{
+			i + 1,
+			e.Time.Local().Format("2006-01-02 15:04:05"),
+			e.Connection,
+			e.Query,
+			status,
+			rows,
+			fmt.Sprintf("%.2fs", e.Duration.Seconds()),
+			strings.Join(strings.Fields(e.SQL), " "),
+			e.Error,
+		}
+	}
+	return db.NewTableData(historyColumns, nil, values, nil)
+}
+
+// rerunHistory runs an entry again with the parameter values it ran with, on
+// the connection it ran on.
+func rerunHistory(ctx context.Context, cfg *config.Config, entry history.Entry, cmdExec table.CommandExecutor) {
+	connYAML, ok := cfg.Connections[entry.Connection]
+	if !ok {
+		log.Fatalf("Connection %s no longer exists", entry.Connection)
+	}
+	cfg.CurrentConnection = entry.Connection
+	conn := config.FromConnectionYaml(connYAML)
+
+	sqlText, sqlArgs, usedParams, err := bindQuery(conn, db.Query{Name: entry.Query, SQL: entry.SQL}, entry.Params, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	runStatement(ctx, cfg, conn, statement{name: entry.Query, sql: entry.SQL, text: sqlText, args: sqlArgs, params: usedParams}, "", false, cmdExec)
+}
+
+// saveHistoryQuery asks for a name and saves an entry as a query of the
+// connection it ran on.
+func saveHistoryQuery(cfg *config.Config, entry history.Entry) {
+	connYAML, ok := cfg.Connections[entry.Connection]
+	if !ok {
+		log.Fatalf("Connection %s no longer exists", entry.Connection)
+	}
+
+	fmt.Printf("Save as query on %s: ", entry.Connection)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	name := strings.TrimSpace(line)
+	if name == "" {
+		if err != nil {
+			fmt.Println()
+		}
+		fmt.Println("No name given, nothing saved")
+		return
+	}
+	if _, exists := connYAML.Queries[name]; exists {
+		log.Fatalf("Query %s already exists on %s", name, entry.Connection)
+	}
+
+	if connYAML.Queries == nil {
+		connYAML.Queries = make(map[string]db.Query)
+	}
+	connYAML.Queries[name] = db.Query{
+		Name: name,
+		SQL:  entry.SQL,
+		Id:   db.GetNextQueryId(connYAML.Queries),
+	}
+	cfg.Connections[entry.Connection] = connYAML
+	if err := cfg.Save(); err != nil {
+		log.Fatal("Could not save configuration file")
+	}
+	fmt.Printf("✓ Added query '%s' with ID %d\n", name, connYAML.Queries[name].Id)
+}
+
+// recordHistory adds a run to the history. Failing to write it never stops
+// the command.
+func recordHistory(cfg *config.Config, entry history.Entry) {
+	if cfg == nil {
+		return
+	}
+	if err := history.Append(config.HistoryFile, entry, history.Size(cfg.History.Size)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record history: %v\n", err)
+	}
 }
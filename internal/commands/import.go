@@ -0,0 +1,312 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eduardofuncao/pam/internal/config"
+	"github.com/eduardofuncao/pam/internal/db"
+	"github.com/eduardofuncao/pam/internal/format"
+)
+
+const importUsage = "Usage: pam import <file|-> [--table <name>] [--format csv|tsv|json|ndjson] [--no-header] [--delimiter <char>] [--null <text>] [--truncate | --upsert [--key <col,...>]] [--create] [--batch <rows>] [--continue-on-error]"
+
+// importSample is how many records the column types of a created table are
+// guessed from.
+const importSample = 1000
+
+// importKinds are the ParamTypes a created column can get, narrowest first;
+// a column that fits none of them is a string.
+var importKinds = []string{"int", "float", "bool", "date"}
+
+// importNumber matches the numbers imported as such. Leading zeros mark codes
+// (007, ZIP codes) that are kept as text.
+var importNumber = map[string]*regexp.Regexp{
+	"int":   regexp.MustCompile(`^[-+]?(0|[1-9][0-9]*)$`),
+	"float": regexp.MustCompile(`^[-+]?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`),
+}
+
+// importOptions are the flags of pam import.
+type importOptions struct {
+	loadOptions
+	format string
+	read   format.Options
+}
+
+func Import(cfg *config.Config) {
+	ImportWithArgs(context.Background(), cfg, os.Args)
+}
+
+// ImportWithArgs loads a csv, tsv, json or ndjson file into a table, mapping
+// its fields to columns by name. Rows are inserted in batches inside a single
+// transaction, so a failed import changes nothing; with --continue-on-error
+// the rows that fail are reported and the rest is committed. The file is read
+// as it is inserted, so it can be of any size.
+func ImportWithArgs(ctx context.Context, cfg *config.Config, args []string) {
+	source, opts, err := importArgs(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	in := os.Stdin
+	if source != "-" {
+		if in, err = os.Open(source); err != nil {
+			log.Fatal(err)
+		}
+		defer in.Close()
+	}
+	reader, err := format.NewReader(in, opts.format, opts.read)
+	if err != nil {
+		log.Fatalf("Could not read %s: %v", source, err)
+	}
+
+	conn := config.FromConnectionYaml(cfg.Connections[cfg.CurrentConnection])
+	if err := conn.Open(); err != nil {
+		log.Fatalf("Could not open the connection to %s/%s: %s", conn.GetDbType(), conn.GetName(), err)
+	}
+
+	start := time.Now()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	stopSpinner := startSpinner(true)
+	defer stopSpinner()
+
+	// The first records are held back to guess column types from
+	var sample []loadRow
+	var unreadable []loadFailure
+	for len(sample) < importSample {
+		values, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var recordErr *format.RecordError
+			if !errors.As(err, &recordErr) {
+				stopSpinner()
+				log.Fatalf("Could not read %s: %v", source, err)
+			}
+			unreadable = append(unreadable, loadFailure{recordErr.Position, recordErr.Err})
+			continue
+		}
+		sample = append(sample, loadRow{reader.Position(), values})
+	}
+
+	loader, err := openLoader(ctx, conn, opts.loadOptions, reader.Columns(), func(columns []string) []string {
+		return guessImportKinds(columns, sample)
+	})
+	if err != nil {
+		stopSpinner()
+		log.Fatal(err)
+	}
+	err = importRows(loader, reader, sample, unreadable)
+	if err == nil {
+		err = loader.commit()
+	}
+	stopSpinner()
+	if err != nil {
+		log.Fatal(loadFailed(ctx, loader, "Import", err))
+	}
+
+	printLoadFailures(loader.failures)
+	fmt.Printf("✓ %s (%.2fs)\n", loader.summary("Imported", opts.table), time.Since(start).Seconds())
+	if len(loader.failures) > 0 {
+		os.Exit(1)
+	}
+}
+
+// importRows feeds the loader the sample, then the rest of the file.
+func importRows(loader *tableLoader, reader format.Reader, sample []loadRow, unreadable []loadFailure) error {
+	for _, failure := range unreadable {
+		if err := loader.fail(failure.position, failure.err); err != nil {
+			return err
+		}
+	}
+	add := func(row loadRow) error {
+		values, err := importValues(loader.columns, loader.kinds, row.values)
+		if err != nil {
+			return loader.fail(row.position, err)
+		}
+		return loader.add(loadRow{row.position, values})
+	}
+
+	for _, row := range sample {
+		if err := add(row); err != nil {
+			return err
+		}
+	}
+	for {
+		values, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		var recordErr *format.RecordError
+		if errors.As(err, &recordErr) {
+			err = loader.fail(recordErr.Position, recordErr.Err)
+		} else if err == nil {
+			err = add(loadRow{reader.Position(), values})
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// importArgs reads the import flags; the table defaults to the file name.
+func importArgs(args []string) (string, importOptions, error) {
+	args, load, err := loadArgs(args)
+	if err != nil {
+		return "", importOptions{}, err
+	}
+	opts := importOptions{loadOptions: load}
+	if args, opts.table, _, err = takeOption(args, "--table", "-t"); err != nil {
+		return "", opts, err
+	}
+	if args, opts.format, _, err = takeOption(args, "--format"); err != nil {
+		return "", opts, err
+	}
+	args, delimiter, hasDelimiter, err := takeOption(args, "--delimiter", "-d")
+	if err != nil {
+		return "", opts, err
+	}
+	args, null, hasNull, err := takeOption(args, "--null")
+	if err != nil {
+		return "", opts, err
+	}
+	var noHeader bool
+	args, noHeader = takeFlag(args, "--no-header")
+	if len(args) != 3 {
+		return "", opts, fmt.Errorf("%s", importUsage)
+	}
+	source := args[2]
+
+	if opts.format == "" {
+		if source == "-" {
+			opts.format = format.CSV
+		} else if opts.format, err = format.FromExtension(source); err != nil {
+			return "", opts, err
+		}
+	}
+	if !slices.Contains(format.Readable, opts.format) {
+		return "", opts, fmt.Errorf("can't import %s files (use %s)", opts.format, strings.Join(format.Readable, ", "))
+	}
+	opts.read = format.Defaults(opts.format)
+	opts.read.Header = !noHeader
+	if hasDelimiter {
+		if opts.read.Delimiter, err = exportDelimiter(delimiter); err != nil {
+			return "", opts, err
+		}
+	}
+	if hasNull {
+		opts.read.Null = null
+	}
+
+	if opts.table == "" {
+		if source == "-" {
+			return "", opts, fmt.Errorf("importing from stdin needs --table <name>")
+		}
+		opts.table = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	}
+	return source, opts, nil
+}
+
+// guessImportKinds picks for every column the narrowest kind all its values
+// in the sample fit; columns without values are strings.
+func guessImportKinds(columns []string, sample []loadRow) []string {
+	kinds := make([]string, len(columns))
+	for i := range columns {
+		fits := slices.Clone(importKinds)
+		seen := false
+		for _, row := range sample {
+			if i >= len(row.values) || row.values[i] == nil {
+				continue
+			}
+			seen = true
+			text := importText(row.values[i])
+			fits = slices.DeleteFunc(fits, func(kind string) bool {
+				_, err := importValue(kind, text)
+				return err != nil
+			})
+		}
+		kinds[i] = "string"
+		if seen && len(fits) > 0 {
+			kinds[i] = fits[0]
+		}
+	}
+	return kinds
+}
+
+// importValues readies a record for binding. Columns of a created table get
+// values of their kind; otherwise the engine converts text, and JSON numbers
+// that aren't integers go as text so no precision is lost.
+func importValues(columns, kinds []string, record []any) ([]any, error) {
+	if len(record) != len(columns) {
+		return nil, fmt.Errorf("%d fields, the table has %d columns", len(record), len(columns))
+	}
+	values := make([]any, len(record))
+	for i, v := range record {
+		if v == nil {
+			continue
+		}
+		if kinds != nil && kinds[i] != "string" {
+			text := importText(v)
+			converted, err := importValue(kinds[i], text)
+			if err != nil {
+				return nil, fmt.Errorf("%s wants %s, got %q", columns[i], kinds[i], text)
+			}
+			values[i] = converted
+			continue
+		}
+		switch v := v.(type) {
+		case json.Number:
+			if n, err := v.Int64(); err == nil && kinds == nil {
+				values[i] = n
+			} else {
+				values[i] = v.String()
+			}
+		case bool:
+			if kinds == nil {
+				values[i] = v
+			} else {
+				values[i] = strconv.FormatBool(v)
+			}
+		default:
+			values[i] = v
+		}
+	}
+	return values, nil
+}
+
+// importValue converts text to a value of kind, stricter than parameters
+// are: numbers take no leading zeros and bools are only true or false.
+func importValue(kind, text string) (any, error) {
+	text = strings.TrimSpace(text)
+	if re, ok := importNumber[kind]; ok && !re.MatchString(text) {
+		return nil, fmt.Errorf("not %s: %q", kind, text)
+	}
+	if kind == "bool" && !strings.EqualFold(text, "true") && !strings.EqualFold(text, "false") {
+		return nil, fmt.Errorf("not bool: %q", text)
+	}
+	return db.Param{Type: kind}.Convert(text)
+}
+
+func importText(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	return fmt.Sprint(v)
+}
@@ -1,10 +1,10 @@
 package commands
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/eduardofuncao/pam/internal/config"
@@ -12,6 +12,10 @@ import (
 	"github.com/eduardofuncao/pam/internal/editor"
 )
 
+var listTitleStyle = lipgloss.NewStyle().
+	Bold(true).
+	Foreground(lipgloss.Color("205"))
+
 func List(cfg *config.Config) {
 	var objectType string
 	if len(os.Args) < 3 {
@@ -27,83 +31,87 @@ func List(cfg *config.Config) {
 		}
 
 	case "queries":
-		titleStyle := lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("205"))
-
 		for _, query := range cfg.Connections[cfg.CurrentConnection].Queries {
 			formatedItem := fmt.Sprintf("\n◆ %d/%s", query.Id, query.Name)
-			fmt.Println(titleStyle.Render(formatedItem))
+			fmt.Println(listTitleStyle.Render(formatedItem))
 			fmt.Println(editor.HighlightSQL(editor.FormatSQLWithLineBreaks(query.SQL)))
 		}
 
 	case "tables":
 		ListTables(cfg)
 
+	case "views":
+		ListViews(cfg)
+
+	case "schemas":
+		ListSchemas(cfg)
+
 	default:
-		log.Fatalf("Unknown list type: %s. Use queries, connections, or tables", objectType)
+		log.Fatalf("Unknown list type: %s. Use queries, connections, tables, views or schemas", objectType)
 	}
 }
 
 func ListTables(cfg *config.Config) {
-	currConn := config.FromConnectionYaml(cfg.Connections[cfg.CurrentConnection])
+	listRelations(cfg, "Tables", func(in db.Introspector, schema string) ([]db.TableInfo, error) {
+		return in.Tables(schema)
+	})
+}
 
-	err := currConn.Open()
+func ListViews(cfg *config.Config) {
+	listRelations(cfg, "Views", func(in db.Introspector, schema string) ([]db.TableInfo, error) {
+		return in.Views(schema)
+	})
+}
+
+func ListSchemas(cfg *config.Config) {
+	currConn, in := openIntrospector(cfg)
+	defer currConn.Close()
+
+	schemas, err := in.Schemas()
 	if err != nil {
-		log.Fatalf("Could not open connection: %v", err)
+		log.Fatalf("Could not list schemas: %v", err)
 	}
-	defer currConn.Close()
 
-	dbType := currConn.GetDbType()
-	var querySQL string
-
-	switch dbType {
-	case "sqlite3":
-		querySQL = "SELECT name FROM sqlite_master WHERE type='table' ORDER BY name"
-	case "postgres":
-		querySQL = "SELECT tablename FROM pg_tables WHERE schemaname='public' ORDER BY tablename"
-	case "mysql":
-		querySQL = "SHOW TABLES"
-	case "oracle":
-		querySQL = "SELECT table_name FROM user_tables ORDER BY table_name"
-	default:
-		log.Fatalf("Unsupported database type for listing tables: %s", dbType)
+	fmt.Println(listTitleStyle.Render("\nSchemas:"))
+	for _, schema := range schemas {
+		fmt.Printf("◆ %s\n", schema)
 	}
+}
+
+func listRelations(cfg *config.Config, title string, fetch func(db.Introspector, string) ([]db.TableInfo, error)) {
+	currConn, in := openIntrospector(cfg)
+	defer currConn.Close()
 
-	queries := currConn.GetQueries()
-	if queries == nil {
-		queries = make(map[string]db.Query)
+	schema := ""
+	if len(os.Args) > 3 {
+		parts, err := db.ResolveName(currConn.GetDialect(), os.Args[3])
+		if err != nil {
+			log.Fatalf("Invalid schema name: %v", err)
+		}
+		schema = parts[len(parts)-1]
 	}
-	tempQueryName := "__list_tables_temp__"
-	queries[tempQueryName] = db.Query{Name: tempQueryName, SQL: querySQL}
-	currConn.SetQueries(queries)
 
-	rows, err := currConn.Query(tempQueryName)
+	relations, err := fetch(in, schema)
 	if err != nil {
-		log.Fatalf("Could not list tables: %v", err)
+		log.Fatalf("Could not list %s: %v", strings.ToLower(title), err)
 	}
 
-	sqlRows, ok := rows.(*sql.Rows)
-	if !ok {
-		log.Fatal("Query did not return *sql.Rows")
+	fmt.Println(listTitleStyle.Render("\n" + title + ":"))
+	for _, r := range relations {
+		fmt.Printf("◆ %s\n", r.Name)
 	}
-	defer sqlRows.Close()
-
-	titleStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("205"))
+}
 
-	fmt.Println(titleStyle.Render("\nTables:"))
+func openIntrospector(cfg *config.Config) (db.DatabaseConnection, db.Introspector) {
+	currConn := config.FromConnectionYaml(cfg.Connections[cfg.CurrentConnection])
 
-	for sqlRows.Next() {
-		var tableName string
-		if err := sqlRows.Scan(&tableName); err != nil {
-			log.Fatalf("Error scanning table name: %v", err)
-		}
-		fmt.Printf("◆ %s\n", tableName)
+	if err := currConn.Open(); err != nil {
+		log.Fatalf("Could not open connection: %v", err)
 	}
 
-	if err := sqlRows.Err(); err != nil {
-		log.Fatalf("Error iterating rows: %v", err)
+	in, err := db.NewIntrospector(currConn)
+	if err != nil {
+		log.Fatalf("Could not read schema: %v", err)
 	}
+	return currConn, in
 }
@@ -0,0 +1,382 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/eduardofuncao/pam/internal/db"
+)
+
+const (
+	loadBatchSize = 500
+	// loadMaxParams keeps a batch under the bind parameter limit of the
+	// engines (sqlite allows 32766)
+	loadMaxParams = 30000
+	// loadShownFailures is how many failed rows are listed
+	loadShownFailures = 20
+	loadSavepoint     = "pam_load"
+)
+
+// errLoadStopped ends a load at the first failed row.
+var errLoadStopped = errors.New("stopped at a failed row")
+
+// loadOptions say how rows go into the target table, for import and copy.
+type loadOptions struct {
+	table           string
+	truncate        bool
+	upsert          bool
+	keys            []string // upsert keys, the primary key when nil
+	create          bool
+	primaryKey      []string // of a created table, unless keys are given
+	batch           int
+	continueOnError bool
+}
+
+// loadRow is a row on its way into the table; position tells where it came
+// from, e.g. "line 3".
+type loadRow struct {
+	position string
+	values   []any
+}
+
+// loadFailure is a row that could not be read or inserted.
+type loadFailure struct {
+	position string
+	err      error
+}
+
+// tableLoader inserts rows into a table in batches inside one transaction,
+// so a load that stops changes nothing. Each batch runs behind a savepoint;
+// when one fails its rows are tried one by one to find the failing ones,
+// which continueOnError skips.
+type tableLoader struct {
+	ctx       context.Context
+	tx        *sql.Tx
+	dialect   db.Dialect
+	opts      loadOptions
+	columns   []string // exact target columns, one per field
+	kinds     []string // kinds of a created table's columns, nil otherwise
+	keys      []string
+	batchRows int
+	batchSQL  string
+	rowSQL    string
+	batch     []loadRow
+	loaded    int
+	failures  []loadFailure
+	created   bool
+	progress  func(loaded int)
+}
+
+// openLoader starts loading into opts.table. fields name the values of every
+// row, matched to the columns by name; nil means every column in order.
+// A missing table is created with create, its columns named after the fields
+// and typed by guessKinds.
+func openLoader(ctx context.Context, conn db.DatabaseConnection, opts loadOptions, fields []string, guessKinds func(columns []string) []string) (*tableLoader, error) {
+	d := conn.GetDialect()
+	if opts.batch <= 0 {
+		opts.batch = loadBatchSize
+	}
+	l := &tableLoader{ctx: ctx, dialect: d, opts: opts}
+
+	// The catalog is read first: an in-memory sqlite database has only the
+	// connection the transaction takes
+	schema, err := db.DescribeTable(conn, opts.table)
+	switch {
+	case errors.Is(err, db.ErrTableNotFound) && opts.create:
+		if l.columns, err = loadColumnNames(d, fields); err != nil {
+			return nil, fmt.Errorf("could not create table %s: %w", opts.table, err)
+		}
+		l.kinds = guessKinds(l.columns)
+		schema = &db.TableSchema{Name: opts.table}
+		for _, col := range l.columns {
+			schema.Columns = append(schema.Columns, db.ColumnInfo{Name: col, Nullable: true})
+		}
+		if opts.primaryKey != nil {
+			if schema.PrimaryKey, err = loadColumns(schema, opts.primaryKey); err != nil {
+				return nil, fmt.Errorf("could not create table %s with its primary key: %w", opts.table, err)
+			}
+		}
+	case errors.Is(err, db.ErrTableNotFound):
+		return nil, fmt.Errorf("table %s does not exist (use --create to create it)", opts.table)
+	case err != nil:
+		return nil, fmt.Errorf("could not read table %s: %w", opts.table, err)
+	default:
+		if l.columns, err = loadColumns(schema, fields); err != nil {
+			return nil, err
+		}
+	}
+	if opts.keys != nil {
+		if schema.PrimaryKey, err = loadColumns(schema, opts.keys); err != nil {
+			return nil, err
+		}
+	}
+	if opts.upsert {
+		l.keys = schema.PrimaryKey
+		if len(l.keys) == 0 {
+			return nil, fmt.Errorf("--upsert needs --key <col,...>: %s has no primary key", opts.table)
+		}
+		for _, key := range l.keys {
+			if !slices.Contains(l.columns, key) {
+				return nil, fmt.Errorf("--upsert needs the key column %s", key)
+			}
+		}
+	}
+
+	l.batchRows = max(1, min(opts.batch, loadMaxParams/max(len(l.columns), 1)))
+	if l.batchSQL, err = db.InsertRowsSQL(d, opts.table, l.columns, l.keys, l.batchRows); err != nil {
+		return nil, err
+	}
+	l.rowSQL, _ = db.InsertRowsSQL(d, opts.table, l.columns, l.keys, 1)
+
+	if l.tx, err = conn.GetDB().BeginTx(ctx, nil); err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	if l.kinds != nil {
+		// On engines with transactional DDL a failed load drops it again
+		query, err := db.CreateTableSQL(d, opts.table, l.columns, l.kinds, schema.PrimaryKey)
+		if err == nil {
+			_, err = l.tx.ExecContext(ctx, query)
+		}
+		if err != nil {
+			l.tx.Rollback()
+			return nil, fmt.Errorf("could not create table %s: %w", opts.table, err)
+		}
+		l.created = true
+	}
+	if opts.truncate {
+		table, _ := db.QuoteName(d, opts.table)
+		// DELETE rather than TRUNCATE, which commits on its own on some engines
+		if _, err := l.tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			l.tx.Rollback()
+			return nil, fmt.Errorf("could not empty %s: %w", opts.table, err)
+		}
+	}
+	return l, nil
+}
+
+// fail records a row that can't be loaded, which stops the load unless
+// continueOnError is set.
+func (l *tableLoader) fail(position string, err error) error {
+	l.failures = append(l.failures, loadFailure{position, err})
+	if !l.opts.continueOnError {
+		return errLoadStopped
+	}
+	return nil
+}
+
+// add queues a row, inserting the batch once it is full.
+func (l *tableLoader) add(row loadRow) error {
+	if len(row.values) != len(l.columns) {
+		return l.fail(row.position, fmt.Errorf("%d fields for %d columns", len(row.values), len(l.columns)))
+	}
+	l.batch = append(l.batch, row)
+	if len(l.batch) < l.batchRows {
+		return nil
+	}
+	return l.flush()
+}
+
+// flush inserts the queued rows.
+func (l *tableLoader) flush() error {
+	if len(l.batch) == 0 {
+		return nil
+	}
+	sqlText := l.batchSQL
+	if len(l.batch) < l.batchRows {
+		sqlText, _ = db.InsertRowsSQL(l.dialect, l.opts.table, l.columns, l.keys, len(l.batch))
+	}
+	var args []any
+	for _, row := range l.batch {
+		args = append(args, row.values...)
+	}
+	batch := l.batch
+	l.batch = l.batch[:0]
+
+	if err := l.exec(sqlText, args); err == nil {
+		l.loaded += len(batch)
+		l.reportProgress()
+		return nil
+	}
+	if err := l.ctx.Err(); err != nil {
+		return err
+	}
+	for _, row := range batch {
+		if err := l.exec(l.rowSQL, row.values); err != nil {
+			if ctxErr := l.ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err := l.fail(row.position, err); err != nil {
+				return err
+			}
+			continue
+		}
+		l.loaded++
+	}
+	l.reportProgress()
+	return nil
+}
+
+// exec runs a statement that, if it fails, is undone without aborting the
+// transaction.
+func (l *tableLoader) exec(query string, args []any) error {
+	if _, err := l.tx.ExecContext(l.ctx, "SAVEPOINT "+loadSavepoint); err != nil {
+		return err
+	}
+	_, err := l.tx.ExecContext(l.ctx, query, args...)
+	if err != nil {
+		l.tx.ExecContext(l.ctx, "ROLLBACK TO SAVEPOINT "+loadSavepoint)
+	}
+	if release := l.dialect.ReleaseSavepoint(loadSavepoint); release != "" {
+		l.tx.ExecContext(l.ctx, release)
+	}
+	return err
+}
+
+func (l *tableLoader) reportProgress() {
+	if l.progress != nil {
+		l.progress(l.loaded)
+	}
+}
+
+// commit inserts what is left and commits the load.
+func (l *tableLoader) commit() error {
+	if err := l.flush(); err != nil {
+		return err
+	}
+	return l.tx.Commit()
+}
+
+func (l *tableLoader) rollback() {
+	l.tx.Rollback()
+}
+
+// summary reports the outcome, e.g. "Imported 3 rows into users, 1 failed",
+// target naming the table.
+func (l *tableLoader) summary(verb, target string) string {
+	s := fmt.Sprintf("%s %d rows into %s", verb, l.loaded, target)
+	if l.created {
+		s = fmt.Sprintf("Created %s and %s %d rows", target, strings.ToLower(verb), l.loaded)
+	}
+	if len(l.failures) > 0 {
+		s += fmt.Sprintf(", %d failed", len(l.failures))
+	}
+	return s
+}
+
+// loadColumns maps field names to the columns of the table, exactly or else
+// ignoring case. Without names every column is filled, in order.
+func loadColumns(schema *db.TableSchema, fields []string) ([]string, error) {
+	if fields == nil {
+		columns := make([]string, len(schema.Columns))
+		for i, col := range schema.Columns {
+			columns[i] = col.Name
+		}
+		return columns, nil
+	}
+
+	columns := make([]string, len(fields))
+	var unknown []string
+	for i, field := range fields {
+		if j := slices.IndexFunc(schema.Columns, func(c db.ColumnInfo) bool { return c.Name == field }); j >= 0 {
+			columns[i] = schema.Columns[j].Name
+		} else if col, ok := schema.Column(field); ok {
+			columns[i] = col.Name
+		} else {
+			unknown = append(unknown, field)
+			continue
+		}
+		if slices.Contains(columns[:i], columns[i]) {
+			return nil, fmt.Errorf("column %s is given twice", columns[i])
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%s has no column %s", schema.Name, strings.Join(unknown, ", "))
+	}
+	return columns, nil
+}
+
+// loadColumnNames names the columns of a table to create after the fields,
+// folded like unquoted names.
+func loadColumnNames(d db.Dialect, fields []string) ([]string, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("the column names come from a header, the keys of a json object or a query")
+	}
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		name := strings.TrimSpace(field)
+		if name == "" {
+			return nil, fmt.Errorf("field %d has no name", i+1)
+		}
+		if parts, err := db.ResolveName(d, name); err == nil && len(parts) == 1 {
+			name = parts[0]
+		}
+		if slices.Contains(columns[:i], name) {
+			return nil, fmt.Errorf("column %s is given twice", name)
+		}
+		columns[i] = name
+	}
+	return columns, nil
+}
+
+// loadFailed ends a load that didn't make it, rolled back.
+func loadFailed(ctx context.Context, l *tableLoader, what string, err error) error {
+	l.rollback()
+	printLoadFailures(l.failures)
+	switch {
+	case ctx.Err() != nil:
+		return fmt.Errorf("%s cancelled, nothing was changed", what)
+	case errors.Is(err, errLoadStopped):
+		return fmt.Errorf("%s stopped, nothing was changed (--continue-on-error skips failed rows)", what)
+	}
+	return fmt.Errorf("%s failed, nothing was changed: %w", what, err)
+}
+
+func printLoadFailures(failures []loadFailure) {
+	for i, failure := range failures {
+		if i == loadShownFailures {
+			fmt.Println(scriptErrorStyle.Render(fmt.Sprintf("  ... and %d more", len(failures)-i)))
+			break
+		}
+		fmt.Println(scriptErrorStyle.Render(fmt.Sprintf("  %s: %v", failure.position, failure.err)))
+	}
+}
+
+// loadArgs takes the flags of a load out of args.
+func loadArgs(args []string) ([]string, loadOptions, error) {
+	opts := loadOptions{batch: loadBatchSize}
+	args, keys, _, err := takeOption(args, "--key")
+	if err != nil {
+		return nil, opts, err
+	}
+	args, batch, hasBatch, err := takeOption(args, "--batch")
+	if err != nil {
+		return nil, opts, err
+	}
+	args, opts.truncate = takeFlag(args, "--truncate")
+	args, opts.upsert = takeFlag(args, "--upsert")
+	args, opts.create = takeFlag(args, "--create")
+	args, opts.continueOnError = takeFlag(args, "--continue-on-error")
+
+	if keys != "" {
+		if !opts.upsert {
+			return nil, opts, fmt.Errorf("--key applies to --upsert")
+		}
+		for _, key := range strings.Split(keys, ",") {
+			opts.keys = append(opts.keys, strings.TrimSpace(key))
+		}
+	}
+	if opts.truncate && opts.upsert {
+		return nil, opts, fmt.Errorf("--truncate and --upsert can't be combined: an emptied table has nothing to update")
+	}
+	if hasBatch {
+		if opts.batch, err = strconv.Atoi(batch); err != nil || opts.batch < 1 {
+			return nil, opts, fmt.Errorf("--batch wants a number of rows, got %q", batch)
+		}
+	}
+	return args, opts, nil
+}
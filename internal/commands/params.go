@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/eduardofuncao/pam/internal/db"
+	"github.com/eduardofuncao/pam/internal/prompt"
+)
+
+// splitParamArgs takes the --name=value parameter flags out of args.
+func splitParamArgs(args []string) ([]string, map[string]string) {
+	values := make(map[string]string)
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		name, value, isParam := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if strings.HasPrefix(arg, "--") && isParam && name != "" {
+			values[name] = value
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, values
+}
+
+// bindQuery binds the :name parameters of a query to driver parameters. Values
+// come from --name=value flags and then from the declared defaults; in a
+// terminal the missing ones are asked for in a form. It returns the SQL to
+// run, its arguments and every value used, for re-running the query later.
+func bindQuery(conn db.DatabaseConnection, query db.Query, values map[string]string, fromTUI bool) (string, []any, map[string]string, error) {
+	d := conn.GetDialect()
+	params := db.QueryParams(d, query)
+
+	known := make(map[string]bool, len(params))
+	for _, p := range params {
+		known[p.Name] = true
+	}
+	for name := range values {
+		if !known[name] {
+			if len(params) == 0 {
+				return "", nil, nil, fmt.Errorf("unknown parameter --%s: the query has no :name parameters", name)
+			}
+			return "", nil, nil, fmt.Errorf("unknown parameter --%s (the query takes %s)", name, paramList(params))
+		}
+	}
+	if len(params) == 0 {
+		return query.SQL, nil, nil, nil
+	}
+
+	var missing []db.Param
+	for _, p := range params {
+		if _, ok := values[p.Name]; !ok && p.Default == nil {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		if fromTUI || !isTerminal(os.Stdin) {
+			return "", nil, nil, fmt.Errorf("missing value for %s (pass --name=value)", paramList(missing))
+		}
+		title := "Parameters"
+		if query.Name != "" {
+			title += " for " + query.Name
+		}
+		filled, err := prompt.Params(title, params, values)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		values = filled
+	}
+
+	sql, args, err := db.BindParams(d, query.SQL, params, values)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	used := make(map[string]string, len(params))
+	for _, p := range params {
+		if v, ok := values[p.Name]; ok {
+			used[p.Name] = v
+		} else {
+			used[p.Name] = *p.Default
+		}
+	}
+	return sql, args, used, nil
+}
+
+func paramList(params []db.Param) string {
+	names := make([]string, len(params))
+	for i, p := range params {
+		names[i] = ":" + p.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -1,32 +1,73 @@
 package commands
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/eduardofuncao/pam/internal/config"
 	"github.com/eduardofuncao/pam/internal/db"
 	"github.com/eduardofuncao/pam/internal/editor"
+	"github.com/eduardofuncao/pam/internal/format"
+	"github.com/eduardofuncao/pam/internal/history"
 	"github.com/eduardofuncao/pam/internal/spinner"
 	"github.com/eduardofuncao/pam/internal/table"
 )
 
 func Run(cfg *config.Config) {
-	RunWithArgs(cfg, os.Args, false, nil)
+	RunWithArgs(context.Background(), cfg, os.Args, false, nil)
 }
 
-func RunWithArgs(cfg *config.Config, args []string, fromTUI bool, cmdExec table.CommandExecutor) (*db.TableData, error) {
+func RunWithArgs(ctx context.Context, cfg *config.Config, args []string, fromTUI bool, cmdExec table.CommandExecutor) (*db.TableData, error) {
 	currConn := config.FromConnectionYaml(cfg.Connections[cfg.CurrentConnection])
+	args, output, _, err := takeOption(args, "--format")
+	if err == nil {
+		output, err = outputFormat(output, fromTUI)
+	}
+	if err != nil {
+		if fromTUI {
+			return nil, err
+		}
+		log.Fatal(err)
+	}
+	args, paramValues := splitParamArgs(args)
+	args, showSQL := takeFlag(args, "--show-sql")
+	opts := runOptions{showSQL: showSQL, format: output}
 
 	if len(args) < 3 {
 		if fromTUI {
 			return nil, fmt.Errorf("usage: run <query-name|sql>")
 		}
-		log.Fatal("Usage: pam run <query-name> [--edit|-e]\n       pam run --edit|-e\n       pam run '<raw-sql>'")
+		log.Fatal("Usage: pam run <query-name> [--edit|-e] [--show-sql] [--format <format>] [--param=value ...]\n       pam run --edit|-e\n       pam run '<raw-sql>' [--show-sql] [--format <format>]\n       pam run -f <file.sql>|- [--continue-on-error] [--transaction] [--format <format>]")
+	}
+
+	source, scriptOpts, isScript, err := scriptArgs(args)
+	if err != nil {
+		if fromTUI {
+			return nil, err
+		}
+		log.Fatal(err)
+	}
+	if isScript {
+		if fromTUI {
+			return nil, fmt.Errorf("scripts can't run from the TUI, use pam run -f in a shell")
+		}
+		script, err := readScript(source)
+		if err != nil {
+			log.Fatalf("Could not read script: %v", err)
+		}
+		scriptOpts.format = output
+		runScript(ctx, cfg, currConn, script, scriptOpts)
+		return nil, nil
 	}
 
 	editFlag := hasEditFlagArgs(args)
@@ -35,7 +76,7 @@ func RunWithArgs(cfg *config.Config, args []string, fromTUI bool, cmdExec table.
 		if fromTUI {
 			return nil, fmt.Errorf("--edit flag not supported in TUI mode")
 		}
-		executeOneShot(currConn, cfg, cmdExec)
+		executeOneShot(ctx, currConn, cfg, paramValues, opts, cmdExec)
 		return nil, nil
 	}
 
@@ -46,7 +87,7 @@ func RunWithArgs(cfg *config.Config, args []string, fromTUI bool, cmdExec table.
 	if !found {
 		rawSQL := strings.Join(args[2:], " ")
 		if looksLikeSQL(rawSQL) {
-			return executeRawSQLWithArgs(currConn, rawSQL, fromTUI, cfg, cmdExec)
+			return executeRawSQLWithArgs(ctx, currConn, rawSQL, paramValues, opts, fromTUI, cfg, cmdExec)
 		}
 		if fromTUI {
 			return nil, fmt.Errorf("could not find query: %v", selector)
@@ -59,72 +100,30 @@ func RunWithArgs(cfg *config.Config, args []string, fromTUI bool, cmdExec table.
 		if submitted {
 			cfg.Connections[cfg.CurrentConnection].Queries[query.Name] = editedQuery
 			cfg.Save()
+			query = editedQuery
 		}
 	}
 
-	err := currConn.Open()
+	query.SQL, err = db.RenderQuery(currConn, query)
 	if err != nil {
 		if fromTUI {
-			return nil, fmt.Errorf("could not open connection: %w", err)
+			return nil, err
 		}
-		log.Fatalf("Could not open the connection to %s/%s: %s", currConn.GetDbType(), currConn.GetName(), err)
+		log.Fatal(err)
 	}
 
-	start := time.Now()
-	var done chan struct{}
-	if !fromTUI {
-		done = make(chan struct{})
-		go spinner.Wait(done)
-	}
-
-	rows, err := currConn.Query(query.Name)
+	sqlText, sqlArgs, usedParams, err := bindQuery(currConn, query, paramValues, fromTUI)
 	if err != nil {
-		if !fromTUI {
-			done <- struct{}{}
-		}
 		if fromTUI {
-			return nil, fmt.Errorf("query failed: %w", err)
+			return nil, err
 		}
-		log.Fatal("Could not complete query: ", err)
+		log.Fatal(err)
 	}
-	sqlRows, ok := rows.(*sql.Rows)
-	if !ok {
-		if fromTUI {
-			return nil, fmt.Errorf("query did not return *sql.Rows")
-		}
-		log.Fatal("Query did not return *sql.Rows")
-	}
-
-	// Check if query returned any columns
-	columns, err := sqlRows.Columns()
-	if err != nil || len(columns) == 0 {
-		// No columns = DML statement, just show success
-		if !fromTUI {
-			done <- struct{}{}
-			elapsed := time.Since(start)
-			fmt.Printf("\nQuery executed successfully (%.2fs)\n", elapsed.Seconds())
-		}
-		sqlRows.Close()
-		return nil, nil
-	}
-
-	tableData, err := db.BuildTableData(sqlRows, query.SQL, currConn)
-	if err != nil {
-		if fromTUI {
-			return nil, fmt.Errorf("error building table data: %w", err)
-		}
-		log.Fatalf("Error building table data: %v", err)
-	}
-
-	if !fromTUI {
-		done <- struct{}{}
-		elapsed := time.Since(start)
-		if err := table.RenderWithExecutor(tableData, elapsed, cmdExec); err != nil {
-			log.Fatalf("Error rendering table: %v", err)
-		}
+	if opts.showSQL && !fromTUI {
+		printSQL(query.SQL, usedParams)
 	}
 
-	return tableData, nil
+	return runStatement(ctx, cfg, currConn, statement{name: query.Name, sql: query.SQL, text: sqlText, args: sqlArgs, params: usedParams}, opts.format, fromTUI, cmdExec)
 }
 
 func hasEditFlag() bool {
@@ -140,132 +139,372 @@ func hasEditFlagArgs(args []string) bool {
 	return false
 }
 
-func looksLikeSQL(s string) bool {
-	keywords := []string{
-		"SELECT", "INSERT", "UPDATE", "DELETE",
-		"CREATE", "ALTER", "DROP", "PRAGMA",
-		"WITH", "EXPLAIN", "DESCRIBE", "SHOW",
+// takeOption removes an option with a value, given as --name value or
+// --name=value under any of its names, from args and returns the value. When
+// it is given more than once, the last value wins.
+func takeOption(args []string, names ...string) (rest []string, value string, found bool, err error) {
+	rest, values, err := takeOptions(args, names...)
+	if err != nil {
+		return nil, "", true, err
 	}
-	upper := strings.ToUpper(strings.TrimSpace(s))
-	for _, kw := range keywords {
-		if strings.HasPrefix(upper, kw) {
-			return true
-		}
+	if len(values) > 0 {
+		return rest, values[len(values)-1], true, nil
 	}
-	return false
+	return rest, "", false, nil
 }
 
-func executeOneShot(currConn db.DatabaseConnection, cfg *config.Config, cmdExec table.CommandExecutor) {
-	emptyQuery := db.Query{Name: "", SQL: ""}
-	editedQuery, _, _ := editor.EditQuery(emptyQuery, true)
-
-	if err := currConn.Open(); err != nil {
-		log.Fatalf("Could not open the connection: %s", err)
+// takeOptions is takeOption for options that can be given more than once,
+// returning every value in order.
+func takeOptions(args []string, names ...string) (rest []string, values []string, err error) {
+	rest = make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name, inline, hasInline := strings.Cut(args[i], "=")
+		if !slices.Contains(names, name) || (hasInline && !strings.HasPrefix(name, "--")) {
+			rest = append(rest, args[i])
+			continue
+		}
+		if hasInline {
+			values = append(values, inline)
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, nil, fmt.Errorf("%s needs a value", name)
+		}
+		values = append(values, args[i+1])
+		i++
 	}
+	return rest, values, nil
+}
 
-	start := time.Now()
-	done := make(chan struct{})
-	go spinner.Wait(done)
+// takeFlag removes a boolean flag from args and reports whether it was there.
+func takeFlag(args []string, flag string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	found := false
+	for _, arg := range args {
+		if arg == flag {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
 
-	rows, err := currConn.QueryDirect(editedQuery.SQL)
-	if err != nil {
-		done <- struct{}{}
-		log.Fatal("Could not complete query: ", err)
+// printSQL shows the statement about to run, templates expanded, followed by
+// the values of its parameters. It goes to stderr so piped output stays clean.
+func printSQL(sqlText string, params map[string]string) {
+	fmt.Fprintln(os.Stderr, strings.TrimSpace(sqlText))
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
 	}
-	sqlRows, ok := rows.(*sql.Rows)
-	if !ok {
-		log.Fatal("Query did not return *sql.Rows")
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "-- :%s = %q\n", name, params[name])
 	}
+	fmt.Fprintln(os.Stderr)
+}
 
-	// Check if query returned any columns
-	columns, err := sqlRows.Columns()
-	if err != nil || len(columns) == 0 {
-		// No columns = DML statement, just show success
-		done <- struct{}{}
-		elapsed := time.Since(start)
-		fmt.Printf("\nQuery executed successfully (%.2fs)\n", elapsed.Seconds())
-		sqlRows.Close()
-		return
+// statementContext bounds a statement by the connection's query timeout and,
+// in the CLI, makes Ctrl+C cancel it instead of killing pam, so the driver
+// stops it on the server. In the TUI the keys are read by the table view,
+// which cancels ctx itself. settle is called once the first rows are in: the
+// rest is read at the user's pace, so the timeout is disarmed and Ctrl+C goes
+// back to the view. cancel ends the statement and releases its rows.
+func statementContext(ctx context.Context, conn db.DatabaseConnection, fromTUI bool) (_ context.Context, settle func(), cancel context.CancelFunc) {
+	ctx, cancelTimeout, disarm := db.WithQueryTimeout(ctx, conn)
+
+	unwatch := func() {}
+	if !fromTUI {
+		interrupts := make(chan os.Signal, 1)
+		signal.Notify(interrupts, os.Interrupt)
+		stopped := make(chan struct{})
+		go func() {
+			select {
+			case <-interrupts:
+				cancelTimeout()
+			case <-stopped:
+			}
+		}()
+		var once sync.Once
+		unwatch = func() {
+			once.Do(func() {
+				signal.Stop(interrupts)
+				close(stopped)
+			})
+		}
 	}
 
-	tableData, err := db.BuildTableData(sqlRows, editedQuery.SQL, currConn)
-	if err != nil {
-		log.Fatalf("Error building table data: %v", err)
+	settle = func() {
+		disarm()
+		unwatch()
+	}
+	cancel = func() {
+		unwatch()
+		cancelTimeout()
 	}
+	return ctx, settle, cancel
+}
 
-	done <- struct{}{}
-	elapsed := time.Since(start)
+var errQueryCancelled = errors.New("query cancelled")
 
-	if err := table.RenderWithExecutor(tableData, elapsed, cmdExec); err != nil {
-		log.Fatalf("Error rendering table: %v", err)
+// queryError reports a failed statement, telling cancellation and timeouts
+// apart from real errors since drivers word them differently.
+func queryError(ctx context.Context, err error) error {
+	switch {
+	case db.TimedOut(ctx):
+		return fmt.Errorf("query timed out: %w", context.Cause(ctx))
+	case ctx.Err() != nil:
+		return errQueryCancelled
 	}
+	return fmt.Errorf("could not complete query: %w", err)
 }
 
-func executeRawSQL(currConn db.DatabaseConnection, query string, cfg *config.Config) {
-	executeRawSQLWithArgs(currConn, query, false, cfg, nil)
+func fatalQuery(ctx context.Context, err error) {
+	switch {
+	case db.TimedOut(ctx):
+		log.Fatal("Query timed out: ", context.Cause(ctx))
+	case ctx.Err() != nil:
+		log.Fatal("Query cancelled")
+	}
+	log.Fatal("Could not complete query: ", err)
+}
+
+// runOptions are the output flags of pam run.
+type runOptions struct {
+	showSQL bool   // print the SQL before it runs
+	format  string // output format, "" for the default
+}
+
+// statement is a query ready to run.
+type statement struct {
+	name   string            // saved query name, if any
+	sql    string            // as written, with :name parameters
+	text   string            // as sent to the driver
+	args   []any             // bound parameter values
+	params map[string]string // parameter values as given, for history and refresh
 }
 
-func executeRawSQLWithArgs(currConn db.DatabaseConnection, query string, fromTUI bool, cfg *config.Config, cmdExec table.CommandExecutor) (*db.TableData, error) {
-	if err := currConn.Open(); err != nil {
+// runStatement runs a statement and shows its outcome: the rows in the given
+// output format, or what it changed. From the TUI the result is returned
+// instead. Every run, failed or not, is added to the history.
+func runStatement(ctx context.Context, cfg *config.Config, conn db.DatabaseConnection, stmt statement, output string, fromTUI bool, cmdExec table.CommandExecutor) (*db.TableData, error) {
+	entry := history.Entry{
+		Time:       time.Now(),
+		Connection: conn.GetName(),
+		Query:      stmt.name,
+		SQL:        stmt.sql,
+		Params:     stmt.params,
+		Rows:       -1,
+	}
+
+	if err := conn.Open(); err != nil {
+		entry.Error = err.Error()
+		recordHistory(cfg, entry)
 		if fromTUI {
 			return nil, fmt.Errorf("could not open connection: %w", err)
 		}
-		log.Fatalf("Could not open the connection: %s", err)
+		log.Fatalf("Could not open the connection to %s/%s: %s", conn.GetDbType(), conn.GetName(), err)
 	}
 
+	ctx, settle, cancel := statementContext(ctx, conn, fromTUI)
+	streamed := false
+	defer func() {
+		// A streamed result releases the statement once its rows are done
+		if !streamed {
+			cancel()
+		}
+	}()
+
 	start := time.Now()
-	var done chan struct{}
-	if !fromTUI {
-		done = make(chan struct{})
-		go spinner.Wait(done)
-	}
+	stopSpinner := startSpinner(!fromTUI)
 
-	rows, err := currConn.QueryDirect(query)
-	if err != nil {
-		if !fromTUI {
-			done <- struct{}{}
-		}
+	fail := func(err error) (*db.TableData, error) {
+		stopSpinner()
+		entry.Duration = time.Since(start)
+		entry.Error = queryError(ctx, err).Error()
+		recordHistory(cfg, entry)
 		if fromTUI {
-			return nil, fmt.Errorf("query failed: %w", err)
+			return nil, queryError(ctx, err)
 		}
-		log.Fatal("Could not complete query: ", err)
+		fatalQuery(ctx, err)
+		return nil, nil
 	}
-	sqlRows, ok := rows.(*sql.Rows)
-	if !ok {
-		if fromTUI {
-			return nil, fmt.Errorf("query did not return *sql.Rows")
+
+	if !db.ReturnsRows(stmt.text) {
+		result, err := db.Exec(ctx, conn, stmt.text, stmt.args...)
+		if err != nil {
+			return fail(err)
 		}
-		log.Fatal("Query did not return *sql.Rows")
+		entry.Duration, entry.Rows = result.Elapsed, result.RowsAffected
+		recordHistory(cfg, entry)
+		return reportExec(result, fromTUI, stopSpinner)
+	}
+
+	limit := conn.GetLimits().RowLimit
+	sqlRows, err := db.QueryCapped(ctx, conn, conn.GetDialect(), stmt.text, limit, stmt.args...)
+	if err != nil {
+		return fail(err)
 	}
 
 	// Check if query returned any columns
 	columns, err := sqlRows.Columns()
 	if err != nil || len(columns) == 0 {
-		// No columns = DML statement, just show success
-		if !fromTUI {
-			done <- struct{}{}
-			elapsed := time.Since(start)
-			fmt.Printf("\nQuery executed successfully (%.2fs)\n", elapsed.Seconds())
+		// No columns after all (PRAGMA, SET ...), so nothing to count
+		if err := finishStatement(sqlRows); err != nil {
+			return fail(err)
 		}
-		sqlRows.Close()
-		return nil, nil
+		entry.Duration = time.Since(start)
+		recordHistory(cfg, entry)
+		return reportExec(&db.ExecResult{RowsAffected: -1, Elapsed: entry.Duration}, fromTUI, stopSpinner)
 	}
 
-	tableData, err := db.BuildTableData(sqlRows, query, currConn)
+	tableData, err := db.StreamTableData(sqlRows, stmt.sql, conn, limit, cancel)
 	if err != nil {
-		if fromTUI {
-			return nil, fmt.Errorf("error building table data: %w", err)
+		return fail(err)
+	}
+	tableData.Params = stmt.params
+	streamed = true
+	settle()
+	entry.Duration = time.Since(start)
+
+	if fromTUI {
+		entry.Rows = int64(len(tableData.Rows))
+		entry.MoreRows = tableData.Stream != nil || tableData.Truncated
+		recordHistory(cfg, entry)
+		return tableData, nil
+	}
+
+	stopSpinner()
+	rows, err := showRows(tableData, entry.Duration, output, cmdExec)
+	// The rows scrolled through in the view are the ones that were read
+	entry.Rows = int64(rows)
+	entry.MoreRows = tableData.Truncated
+	recordHistory(cfg, entry)
+	if err != nil {
+		log.Fatalf("Error rendering table: %v", err)
+	}
+	return tableData, nil
+}
+
+// showRows shows a result in the CLI, in the table view or written out in
+// another format, and returns how many rows were read.
+func showRows(tableData *db.TableData, elapsed time.Duration, output string, cmdExec table.CommandExecutor) (int, error) {
+	if output == "" {
+		output = format.Default(isTerminal(os.Stdout))
+	}
+	if output == format.Table && isTerminal(os.Stdout) {
+		err := table.RenderWithExecutor(tableData, elapsed, cmdExec)
+		tableData.Close()
+		return len(tableData.Rows), err
+	}
+
+	rows, err := format.Write(os.Stdout, output, tableData, format.Defaults(output))
+	tableData.Close()
+	if err == nil && tableData.Truncated {
+		fmt.Fprintf(os.Stderr, "Warning: stopped at %d rows (row_limit)\n", rows)
+	}
+	return rows, err
+}
+
+// outputFormat checks a --format value. Formats only apply to the CLI.
+func outputFormat(name string, fromTUI bool) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	if fromTUI {
+		return "", fmt.Errorf("--format applies to pam run in a shell, not the TUI")
+	}
+	return name, format.Check(name)
+}
+
+// startSpinner shows the time a statement has been running until stop is
+// called. It stays off in the TUI and when stdout isn't a terminal, where it
+// would end up in the output.
+func startSpinner(show bool) (stop func()) {
+	if !show || !isTerminal(os.Stdout) {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go spinner.Wait(done)
+	var once sync.Once
+	return func() {
+		once.Do(func() { done <- struct{}{} })
+	}
+}
+
+// reportExec finishes a statement that returned no rows. The CLI prints what
+// it changed; the TUI gets it back to show in the status line.
+func reportExec(result *db.ExecResult, fromTUI bool, stopSpinner func()) (*db.TableData, error) {
+	if fromTUI {
+		return &db.TableData{Exec: result}, nil
+	}
+	stopSpinner()
+
+	message := "Query executed successfully"
+	if summary := result.Summary(); summary != "" {
+		message += ": " + summary
+	}
+	fmt.Printf("\n%s (%.2fs)\n", message, result.Elapsed.Seconds())
+	return nil, nil
+}
+
+// finishStatement steps through rows that carry no columns before closing them.
+// Some drivers (sqlite) only execute the statement once it is stepped.
+func finishStatement(rows *sql.Rows) error {
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	return rows.Close()
+}
+
+func looksLikeSQL(s string) bool {
+	keywords := []string{
+		"SELECT", "INSERT", "UPDATE", "DELETE",
+		"CREATE", "ALTER", "DROP", "PRAGMA",
+		"WITH", "EXPLAIN", "DESCRIBE", "SHOW",
+	}
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	for _, kw := range keywords {
+		if strings.HasPrefix(upper, kw) {
+			return true
 		}
-		log.Fatalf("Error building table data: %v", err)
 	}
+	return false
+}
 
-	if !fromTUI {
-		done <- struct{}{}
-		elapsed := time.Since(start)
-		if err := table.RenderWithExecutor(tableData, elapsed, cmdExec); err != nil {
-			log.Fatalf("Error rendering table: %v", err)
+func executeOneShot(ctx context.Context, currConn db.DatabaseConnection, cfg *config.Config, paramValues map[string]string, opts runOptions, cmdExec table.CommandExecutor) {
+	emptyQuery := db.Query{Name: "", SQL: ""}
+	editedQuery, _, _ := editor.EditQuery(emptyQuery, true)
+
+	// A buffer holding several statements runs as a script
+	if len(db.SplitStatements(editedQuery.SQL, currConn.GetDialect().ScriptSyntax())) > 1 {
+		runScript(ctx, cfg, currConn, editedQuery.SQL, scriptOptions{format: opts.format})
+		return
+	}
+
+	// Otherwise it is raw SQL like any other, parameters and flags included
+	executeRawSQLWithArgs(ctx, currConn, editedQuery.SQL, paramValues, opts, false, cfg, cmdExec)
+}
+
+func executeRawSQL(currConn db.DatabaseConnection, query string, cfg *config.Config) {
+	executeRawSQLWithArgs(context.Background(), currConn, query, nil, runOptions{}, false, cfg, nil)
+}
+
+func executeRawSQLWithArgs(ctx context.Context, currConn db.DatabaseConnection, query string, paramValues map[string]string, opts runOptions, fromTUI bool, cfg *config.Config, cmdExec table.CommandExecutor) (*db.TableData, error) {
+	sqlText, sqlArgs, usedParams, err := bindQuery(currConn, db.Query{SQL: query}, paramValues, fromTUI)
+	if err != nil {
+		if fromTUI {
+			return nil, err
 		}
+		log.Fatal(err)
+	}
+	if opts.showSQL && !fromTUI {
+		printSQL(query, usedParams)
 	}
 
-	return tableData, nil
+	return runStatement(ctx, cfg, currConn, statement{sql: query, text: sqlText, args: sqlArgs, params: usedParams}, opts.format, fromTUI, cmdExec)
 }
@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/eduardofuncao/pam/internal/config"
+	"github.com/eduardofuncao/pam/internal/db"
+	"github.com/eduardofuncao/pam/internal/format"
+	"github.com/eduardofuncao/pam/internal/history"
+	"github.com/mattn/go-runewidth"
+)
+
+const scriptTitleWidth = 72
+
+var (
+	scriptHeaderStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	scriptErrorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	scriptMutedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+)
+
+// scriptOptions are the flags of a script run.
+type scriptOptions struct {
+	continueOnError bool
+	transaction     bool
+	format          string // how rows are printed, the table grid when ""
+}
+
+// scriptArgs recognizes `run -f <file>` and `run -` (script on stdin) with
+// their --continue-on-error and --transaction flags.
+func scriptArgs(args []string) (source string, opts scriptOptions, isScript bool, err error) {
+	for i := 2; i < len(args); i++ {
+		switch args[i] {
+		case "-f", "--file":
+			if i+1 >= len(args) {
+				return "", opts, true, fmt.Errorf("%s needs a script file", args[i])
+			}
+			source, isScript = args[i+1], true
+			i++
+		case "-":
+			if i == 2 {
+				source, isScript = "-", true
+			}
+		case "--continue-on-error":
+			opts.continueOnError = true
+		case "--transaction", "--tx":
+			opts.transaction = true
+		}
+	}
+	if !isScript && (opts.continueOnError || opts.transaction) {
+		return "", opts, false, fmt.Errorf("--continue-on-error and --transaction apply to scripts (-f <file> or -)")
+	}
+	return source, opts, isScript, nil
+}
+
+// readScript reads a script file, or stdin for "-".
+func readScript(source string) (string, error) {
+	if source == "-" {
+		content, err := io.ReadAll(os.Stdin)
+		return string(content), err
+	}
+	content, err := os.ReadFile(source)
+	return string(content), err
+}
+
+// runScript runs the statements of a script one after the other, reporting
+// each one and printing the rows of those that return any. It stops at the
+// first failure unless continueOnError is set; with transaction the script
+// runs in one transaction that a failure rolls back. pam exits with status 1
+// when a statement failed.
+func runScript(ctx context.Context, cfg *config.Config, conn db.DatabaseConnection, script string, opts scriptOptions) {
+	statements := db.SplitStatements(script, conn.GetDialect().ScriptSyntax())
+	if len(statements) == 0 {
+		log.Fatal("No statements in script")
+	}
+	if opts.transaction && opts.continueOnError {
+		log.Fatal("--continue-on-error can't be combined with --transaction: a failed statement rolls the whole script back")
+	}
+
+	if err := conn.Open(); err != nil {
+		log.Fatalf("Could not open the connection to %s/%s: %s", conn.GetDbType(), conn.GetName(), err)
+	}
+
+	var ex db.Executor = conn
+	var tx *sql.Tx
+	if opts.transaction {
+		var err error
+		tx, err = conn.GetDB().BeginTx(ctx, nil)
+		if err != nil {
+			log.Fatalf("Could not start transaction: %v", err)
+		}
+		ex = tx
+	}
+
+	start := time.Now()
+	failed := 0
+	for i, stmt := range statements {
+		fmt.Fprintln(os.Stderr, scriptHeaderStyle.Render(fmt.Sprintf("[%d/%d] %s", i+1, len(statements), statementTitle(stmt))))
+		if err := runScriptStatement(ctx, cfg, conn, ex, stmt, opts); err != nil {
+			failed++
+			fmt.Fprintln(os.Stderr, scriptErrorStyle.Render("  "+err.Error()))
+			if !opts.continueOnError || errors.Is(err, errQueryCancelled) {
+				if tx != nil {
+					tx.Rollback()
+					log.Fatalf("Stopped at statement %d of %d, transaction rolled back", i+1, len(statements))
+				}
+				log.Fatalf("Stopped at statement %d of %d", i+1, len(statements))
+			}
+		}
+	}
+
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			log.Fatalf("Could not commit transaction: %v", err)
+		}
+	}
+
+	summary := fmt.Sprintf("\n%d statements", len(statements))
+	if failed > 0 {
+		summary += fmt.Sprintf(", %d failed", failed)
+	}
+	if tx != nil {
+		summary += ", committed"
+	}
+	fmt.Fprintf(os.Stderr, "%s (%.2fs)\n", summary, time.Since(start).Seconds())
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// runScriptStatement runs one statement of a script, prints its outcome and
+// adds it to the history.
+func runScriptStatement(ctx context.Context, cfg *config.Config, conn db.DatabaseConnection, ex db.Executor, stmt string, opts scriptOptions) error {
+	ctx, _, cancel := statementContext(ctx, conn, false)
+	defer cancel()
+
+	entry := history.Entry{Time: time.Now(), Connection: conn.GetName(), SQL: stmt, Rows: -1}
+	fail := func(err error) error {
+		err = queryError(ctx, err)
+		entry.Duration = time.Since(entry.Time)
+		entry.Error = err.Error()
+		recordHistory(cfg, entry)
+		return err
+	}
+
+	if !db.ReturnsRows(stmt) {
+		result, err := db.Exec(ctx, ex, stmt)
+		if err != nil {
+			return fail(err)
+		}
+		entry.Duration, entry.Rows = result.Elapsed, result.RowsAffected
+		recordHistory(cfg, entry)
+		printStatementResult(result.Summary(), result.Elapsed)
+		return nil
+	}
+
+	limit := conn.GetLimits().RowLimit
+	rows, err := db.QueryCapped(ctx, ex, conn.GetDialect(), stmt, limit)
+	if err != nil {
+		return fail(err)
+	}
+	if columns, err := rows.Columns(); err != nil || len(columns) == 0 {
+		if err := finishStatement(rows); err != nil {
+			return fail(err)
+		}
+		entry.Duration = time.Since(entry.Time)
+		recordHistory(cfg, entry)
+		printStatementResult("", entry.Duration)
+		return nil
+	}
+
+	// Cancelling the statement once the limit is reached spares reading
+	// the rest of a result the server wasn't asked to cap, but it would
+	// break a transaction
+	release := cancel
+	if _, inTx := ex.(*sql.Tx); inTx {
+		release = nil
+	}
+	tableData, err := db.BuildTableDataLimited(rows, stmt, conn, limit, release)
+	if err != nil {
+		return fail(err)
+	}
+	entry.Duration = time.Since(entry.Time)
+	entry.Rows, entry.MoreRows = int64(len(tableData.Rows)), tableData.Truncated
+	recordHistory(cfg, entry)
+	output := opts.format
+	if output == "" {
+		output = format.Table
+	}
+	if _, err := format.Write(os.Stdout, output, tableData, format.Defaults(output)); err != nil {
+		return err
+	}
+
+	count := fmt.Sprintf("%d rows", len(tableData.Rows))
+	if len(tableData.Rows) == 1 {
+		count = "1 row"
+	}
+	if tableData.Truncated {
+		count += " (truncated by row limit)"
+	}
+	printStatementResult(count, entry.Duration)
+	return nil
+}
+
+func printStatementResult(summary string, elapsed time.Duration) {
+	if summary == "" {
+		summary = "ok"
+	}
+	fmt.Fprintln(os.Stderr, scriptMutedStyle.Render(fmt.Sprintf("  %s (%.2fs)", summary, elapsed.Seconds())))
+}
+
+// statementTitle shortens a statement to one line for its report header.
+func statementTitle(stmt string) string {
+	title := strings.Join(strings.Fields(db.TrimLeadingComments(stmt)), " ")
+	return runewidth.Truncate(title, scriptTitleWidth, "…")
+}
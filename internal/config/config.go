@@ -11,6 +11,7 @@ import (
 const (
 	configDirPath     = "$HOME/.config/pam/"
 	configFileName    = "config.yaml"
+	historyFileName   = "history.jsonl"
 	dirPermissions    = 0755
 	filePermissions   = 0644
 	msgCreatingConfig = "Creating blank config file at"
@@ -18,6 +19,7 @@ const (
 
 var CfgPath = os.ExpandEnv(configDirPath)
 var CfgFile = filepath.Join(CfgPath, configFileName)
+var HistoryFile = filepath.Join(CfgPath, historyFileName)
 
 type Config struct {
 	CurrentConnection string                    `yaml:"current_connection"`
@@ -31,6 +33,7 @@ type Style struct {
 }
 
 type History struct {
+	// Entries kept in the history file; 0 keeps the default, -1 turns it off
 	Size int `yaml:"size"`
 }
 
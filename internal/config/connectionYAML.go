@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/eduardofuncao/pam/internal/db"
 )
@@ -11,6 +12,14 @@ type ConnectionYAML struct {
 	DBType     string         `yaml:"db_type"`
 	ConnString string         `yaml:"conn_string"`
 	Queries    map[string]db.Query `yaml:"queries"`
+
+	// Guards against runaway statements; durations like "30s" or "2m"
+	QueryTimeout   string `yaml:"query_timeout,omitempty"`
+	ConnectTimeout string `yaml:"connect_timeout,omitempty"`
+	RowLimit       int    `yaml:"row_limit,omitempty"`
+
+	// Values for {{ .Vars.name }} in template queries
+	Vars map[string]string `yaml:"vars,omitempty"`
 }
 
 func ToConnectionYAML(conn db.DatabaseConnection) (ConnectionYAML) {
@@ -19,6 +28,10 @@ func ToConnectionYAML(conn db.DatabaseConnection) (ConnectionYAML) {
 		DBType: conn.GetDbType(),
 		ConnString: conn.GetConnString(),
 		Queries: conn.GetQueries(),
+		QueryTimeout: formatDuration(conn.GetLimits().QueryTimeout),
+		ConnectTimeout: formatDuration(conn.GetLimits().ConnectTimeout),
+		RowLimit: conn.GetLimits().RowLimit,
+		Vars: conn.GetVars(),
 	}
 }
 
@@ -28,5 +41,29 @@ func FromConnectionYaml(yc ConnectionYAML) (db.DatabaseConnection) {
 		log.Fatalf("could not create connection from yaml for: %s/%s", yc.DBType, yc.Name)
 	}
 	conn.SetQueries(yc.Queries)
+	conn.SetLimits(db.Limits{
+		QueryTimeout:   parseDuration(yc.Name, "query_timeout", yc.QueryTimeout),
+		ConnectTimeout: parseDuration(yc.Name, "connect_timeout", yc.ConnectTimeout),
+		RowLimit:       yc.RowLimit,
+	})
+	conn.SetVars(yc.Vars)
 	return conn
 }
+
+func parseDuration(connName, key, value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Fatalf("invalid %s %q for connection %s (use e.g. 30s or 2m)", key, value, connName)
+	}
+	return d
+}
+
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
@@ -1,20 +1,28 @@
 package db
 
-import(
+import (
 	"errors"
 	"fmt"
 )
 
 func CreateConnection(name, dbType, connString string) (DatabaseConnection, error) {
-	switch dbType{
-	case "postgres":
-		return NewPostgresConnection(name, connString)
-	case "myslq", "mariadb":
-		return nil, errors.New("mysql driver not implemented, check connection factory")
+	dialect, err := DialectFor(dbType)
+	if err != nil {
+		return nil, err
+	}
+	return NewSQLConnection(name, dialect, connString)
+}
+
+func DialectFor(dbType string) (Dialect, error) {
+	switch dbType {
+	case "postgres", "postgresql":
+		return PostgresDialect{}, nil
+	case "mysql", "mariadb":
+		return MySQLDialect{}, nil
 	case "sqlite", "sqlite3":
-		return nil, errors.New("sqlite driver not implemented, check connection factory")
+		return SQLiteDialect{}, nil
 	case "godror", "oracle":
-		return NewOracleConnection(name, connString)
+		return OracleDialect{}, nil
 	default:
 		return nil, errors.New(fmt.Sprintln("Driver not implemented for ", dbType, ". Check connection factory"))
 	}
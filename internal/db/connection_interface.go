@@ -1,20 +1,30 @@
 package db
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+)
 
 type DatabaseConnection interface {
 	Open() error
 	Ping() error
 	Close() error
-	Query(queryName string, args ...any) (any, error)
-	QueryDirect(sql string, args ...any) (any, error)
+	// QueryContext and ExecContext stop the statement on the server when ctx
+	// is cancelled.
+	QueryContext(ctx context.Context, sql string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, sql string, args ...any) (sql.Result, error)
 
 	GetName() string
 	GetDbType() string
 	GetConnString() string
 	GetQueries() map[string]Query
+	GetLimits() Limits
+	GetVars() map[string]string
 	GetDB() *sql.DB
+	GetDialect() Dialect
 
 	SetQueries(map[string]Query)
+	SetLimits(Limits)
+	SetVars(map[string]string)
 }
 
@@ -6,13 +6,18 @@ import (
 	"log"
 	"regexp"
 	"strings"
+	"time"
 )
 
+const timeLayout = "2006-01-02 15:04:05.999999999"
+
+// namePart matches one part of a table name as written in SQL: a bare
+// identifier, a "double quoted" one or a `backticked` one.
+const namePart = "(?:\"[^\"]+\"|`[^`]+`|[a-zA-Z_][a-zA-Z0-9_$#]*)"
+
 type Cell struct {
 	Value       string // Display value
 	RawValue    any    // Original database value for queries
-	ColumnName  string
-	ColumnType  string
 	RowIndex    int
 	ColumnIndex int
 }
@@ -20,11 +25,16 @@ type Cell struct {
 type Row []Cell
 
 type TableData struct {
-	Columns    []string
-	Rows       []Row
-	TableName  string
-	SQL        string
-	Connection DatabaseConnection
+	Columns     []string
+	ColumnTypes []string // database type name per column, "" when unknown
+	Rows        []Row
+	TableName   string
+	SQL         string
+	Params      map[string]string // values of the :name parameters in SQL
+	Connection  DatabaseConnection
+	Truncated   bool        // rows were left unread (row limit, or loading stopped)
+	Stream      *RowStream  // rows not read yet; nil once every row is loaded
+	Exec        *ExecResult // set, without columns, for statements that return no rows
 }
 
 func FormatTableData(rows *sql.Rows) (columns []string, data [][]string, err error) {
@@ -61,72 +71,122 @@ func FormatTableData(rows *sql.Rows) (columns []string, data [][]string, err err
 }
 
 func BuildTableData(rows *sql.Rows, sqlQuery string, conn DatabaseConnection) (*TableData, error) {
-	columns, err := rows.Columns()
+	return BuildTableDataLimited(rows, sqlQuery, conn, 0, nil)
+}
+
+// BuildTableDataLimited reads at most limit rows (no limit when 0) and marks
+// the result as truncated when more were available. rows is always closed,
+// after release (if any) is called to end the statement.
+func BuildTableDataLimited(rows *sql.Rows, sqlQuery string, conn DatabaseConnection, limit int, release func()) (*TableData, error) {
+	tableData, err := newStreamedTableData(rows, sqlQuery, conn, limit, release)
 	if err != nil {
-		return nil, fmt.Errorf("getting columns: %w", err)
+		return nil, err
+	}
+	if err := tableData.Fetch(0); err != nil {
+		tableData.Close()
+		return nil, err
 	}
+	return tableData, nil
+}
 
-	columnTypes, err := rows.ColumnTypes()
-	if err != nil {
-		return nil, fmt.Errorf("getting column types: %w", err)
+// Fetch loads up to n more rows from the stream, or all of them when n <= 0.
+func (t *TableData) Fetch(n int) error {
+	if t.Stream == nil {
+		return nil
 	}
+	rows, err := t.Stream.Fetch(n)
+	t.Append(rows)
+	return err
+}
 
-	tableName := extractTableName(sqlQuery)
+// Append adds rows read from the stream after the rows already loaded and
+// drops the stream once it has no more to give.
+func (t *TableData) Append(rows []Row) {
+	for _, row := range rows {
+		for i := range row {
+			row[i].RowIndex = len(t.Rows)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	if t.Stream != nil && t.Stream.Done() {
+		t.Truncated = t.Stream.Truncated()
+		t.Stream = nil
+	}
+}
 
-	values := make([]any, len(columns))
-	valuePtrs := make([]any, len(columns))
-	for i := range columns {
-		valuePtrs[i] = &values[i]
+// ColumnType returns the database type name of a column, "" when unknown.
+func (t *TableData) ColumnType(col int) string {
+	if col < 0 || col >= len(t.ColumnTypes) {
+		return ""
+	}
+	return t.ColumnTypes[col]
+}
+
+// Close stops loading rows, leaving the ones already read.
+func (t *TableData) Close() {
+	if t.Stream != nil {
+		t.Stream.Close()
+		t.Append(nil)
 	}
+}
 
-	var tableRows []Row
-	rowIndex := 0
+// FormatValue renders a scanned database value for display.
+func FormatValue(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	case time.Time:
+		return formatTime(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
 
-	for rows.Next() {
-		err = rows.Scan(valuePtrs...)
-		if err != nil {
-			return nil, fmt.Errorf("scanning row: %w", err)
-		}
+// formatTime renders timestamps in a form every engine accepts back as a
+// literal, so edited values round-trip through the TUI.
+func formatTime(t time.Time) string {
+	if t.Location() == time.UTC {
+		return t.Format(timeLayout)
+	}
+	return t.Format(timeLayout + "-07:00")
+}
 
+// NewTableData builds table data from plain values, for views that do not come
+// straight from a query (schema descriptions, history, diffs).
+func NewTableData(columns []string, columnTypes []string, values [][]any, conn DatabaseConnection) *TableData {
+	rows := make([]Row, len(values))
+	for rowIndex, rowValues := range values {
 		row := make(Row, len(columns))
-		for colIndex, val := range values {
+		for colIndex := range columns {
+			var val any
+			if colIndex < len(rowValues) {
+				val = rowValues[colIndex]
+			}
 			cellValue := "NULL"
 			if val != nil {
-				switch v := val.(type) {
-				case []byte:
-					cellValue = string(v)
-				default:
-					cellValue = fmt.Sprintf("%v", v)
-				}
+				cellValue = fmt.Sprintf("%v", val)
 			}
 			row[colIndex] = Cell{
 				Value:       cellValue,
 				RawValue:    val,
-				ColumnName:  columns[colIndex],
-				ColumnType:  columnTypes[colIndex].DatabaseTypeName(),
 				RowIndex:    rowIndex,
 				ColumnIndex: colIndex,
 			}
 		}
-		tableRows = append(tableRows, row)
-		rowIndex++
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterating rows: %w", err)
+		rows[rowIndex] = row
 	}
-
 	return &TableData{
-		Columns:    columns,
-		Rows:       tableRows,
-		TableName:  tableName,
-		SQL:        sqlQuery,
-		Connection: conn,
-	}, nil
+		Columns:     columns,
+		ColumnTypes: columnTypes,
+		Rows:        rows,
+		Connection:  conn,
+	}
 }
 
 func extractTableName(sqlQuery string) string {
-	re := regexp.MustCompile(`(?i)FROM\s+([a-zA-Z_][a-zA-Z0-9_]*)`)
+	re := regexp.MustCompile(`(?i)FROM\s+(` + namePart + `(?:\.` + namePart + `)*)`)
 	matches := re.FindStringSubmatch(sqlQuery)
 	if len(matches) > 1 {
 		return strings.TrimSpace(matches[1])
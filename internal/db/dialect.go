@@ -0,0 +1,374 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var bareIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$#]*$`)
+
+// Dialect holds everything that differs between database engines. The
+// generic SQLConnection and the commands only talk to the engine through it,
+// so supporting a new engine means writing a new Dialect.
+type Dialect interface {
+	// Name is the db_type stored in the config file.
+	Name() string
+	// DriverName is the database/sql driver used to open connections.
+	DriverName() string
+	// NormalizeConnString validates a connection string and rewrites it into
+	// the form that is saved and handed to the driver.
+	NormalizeConnString(connString string) (string, error)
+	// ConfigureDB tunes the connection pool right after it is opened.
+	ConfigureDB(db *sql.DB, connString string)
+	// Placeholder returns the bind parameter for the 1-based index.
+	Placeholder(index int) string
+	// QuoteIdentifier quotes a single identifier that is already in its exact,
+	// case-sensitive form (as reported by the driver or the catalog).
+	QuoteIdentifier(name string) string
+	// FoldIdentifier returns the exact name the engine resolves an unquoted
+	// identifier to.
+	FoldIdentifier(name string) string
+	// LimitRows rewrites a SELECT so it returns at most limit rows, using the
+	// engine's own syntax (LIMIT, FETCH FIRST, TOP).
+	LimitRows(query string, limit int) string
+	// CapsRowsInQuery reports whether the row limit is worth writing into a
+	// SELECT (see CapRows), because the engine would send the whole result
+	// otherwise.
+	CapsRowsInQuery() bool
+	// InsertDefaults builds an INSERT into an already quoted table that sets
+	// no column, so the row is made of column defaults only.
+	InsertDefaults(table string) string
+	// Returning is the clause that makes an INSERT hand back the given quoted
+	// columns of the new row, or "" when the engine can't return rows.
+	Returning(columns []string) string
+	// ScriptSyntax tells the script splitter how strings and procedural
+	// blocks are written.
+	ScriptSyntax() ScriptSyntax
+	// ReadsBlockStatements reports whether an open result set holds up other
+	// statements on the database, so a view has to stop streaming rows before
+	// it runs anything else.
+	ReadsBlockStatements() bool
+	// InsertRows builds an INSERT of several rows into an already quoted
+	// table, each row given as the placeholders of the quoted columns.
+	InsertRows(table string, columns []string, rows [][]string) string
+	// UpsertRows is InsertRows that updates the rows whose keys, quoted
+	// columns among columns, are already in the table.
+	UpsertRows(table string, columns, keys []string, rows [][]string) string
+	// ColumnType is the type a created column gets for one of the
+	// ColumnKinds.
+	ColumnType(kind string) string
+	// ColumnKind tells which of the ColumnKinds a column of the given
+	// database type name (as the driver reports it) holds, "" when the name
+	// says nothing, as for sqlite expressions.
+	ColumnKind(typeName string) string
+	// BoolLiteral, BytesLiteral and TimeLiteral write a value as a literal
+	// of the engine (see Literal).
+	BoolLiteral(b bool) string
+	BytesLiteral(b []byte) string
+	TimeLiteral(t time.Time) string
+	// ReleaseSavepoint forgets a savepoint that is no longer needed, or is ""
+	// when the engine has no such statement.
+	ReleaseSavepoint(name string) string
+	// Introspector reads tables, columns, keys and indexes from the catalog.
+	Introspector(db *sql.DB) Introspector
+}
+
+// ColumnKinds are the kinds of values a column can hold, which each engine
+// maps to its own type when pam creates a table. They take in the ParamTypes.
+var ColumnKinds = []string{"string", "int", "float", "decimal", "bool", "date", "timestamp", "bytes"}
+
+// baseDialect provides the ANSI behavior most engines share. Dialects embed it
+// and override what differs.
+type baseDialect struct{}
+
+func (baseDialect) NormalizeConnString(connString string) (string, error) {
+	return connString, nil
+}
+
+func (baseDialect) ConfigureDB(db *sql.DB, connString string) {}
+
+func (baseDialect) Placeholder(index int) string {
+	return "?"
+}
+
+func (baseDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (baseDialect) FoldIdentifier(name string) string {
+	return name
+}
+
+func (baseDialect) LimitRows(query string, limit int) string {
+	return fmt.Sprintf("%s LIMIT %d", query, limit)
+}
+
+func (baseDialect) InsertDefaults(table string) string {
+	return fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", table)
+}
+
+func (baseDialect) Returning(columns []string) string {
+	return ""
+}
+
+func (baseDialect) InsertRows(table string, columns []string, rows [][]string) string {
+	values := make([]string, len(rows))
+	for i, row := range rows {
+		values[i] = "(" + strings.Join(row, ", ") + ")"
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", table, strings.Join(columns, ", "), strings.Join(values, ", "))
+}
+
+// UpsertRows uses ON CONFLICT, shared by postgres and sqlite.
+func (d baseDialect) UpsertRows(table string, columns, keys []string, rows [][]string) string {
+	var set []string
+	for _, col := range columns {
+		if !slices.Contains(keys, col) {
+			set = append(set, fmt.Sprintf("%s = excluded.%s", col, col))
+		}
+	}
+	action := "DO NOTHING"
+	if len(set) > 0 {
+		action = "DO UPDATE SET " + strings.Join(set, ", ")
+	}
+	return fmt.Sprintf("%s ON CONFLICT (%s) %s", d.InsertRows(table, columns, rows), strings.Join(keys, ", "), action)
+}
+
+func (baseDialect) ColumnType(kind string) string {
+	switch kind {
+	case "int":
+		return "BIGINT"
+	case "float":
+		return "DOUBLE PRECISION"
+	case "decimal":
+		return "NUMERIC"
+	case "bool":
+		return "BOOLEAN"
+	case "date":
+		return "DATE"
+	case "timestamp":
+		return "TIMESTAMP"
+	case "bytes":
+		return "BLOB"
+	}
+	return "TEXT"
+}
+
+func (baseDialect) ColumnKind(typeName string) string {
+	name := strings.ToUpper(strings.TrimSpace(typeName))
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+	name = strings.TrimPrefix(name, "UNSIGNED ")
+	switch name {
+	case "":
+		return ""
+	case "INT", "INTEGER", "INT2", "INT4", "INT8", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT", "SERIAL", "BIGSERIAL", "YEAR":
+		return "int"
+	case "FLOAT", "FLOAT4", "FLOAT8", "REAL", "DOUBLE", "DOUBLE PRECISION", "BINARY_FLOAT", "BINARY_DOUBLE":
+		return "float"
+	case "DECIMAL", "NUMERIC", "NUMBER":
+		return "decimal"
+	case "BOOL", "BOOLEAN":
+		return "bool"
+	case "DATE":
+		return "date"
+	case "DATETIME", "TIMESTAMP", "TIMESTAMPTZ":
+		return "timestamp"
+	case "BYTEA", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "RAW", "LONG RAW":
+		return "bytes"
+	}
+	if strings.HasPrefix(name, "TIMESTAMP") {
+		return "timestamp"
+	}
+	return "string"
+}
+
+func (baseDialect) ReleaseSavepoint(name string) string {
+	return "RELEASE SAVEPOINT " + name
+}
+
+func (baseDialect) ScriptSyntax() ScriptSyntax {
+	return ScriptSyntax{}
+}
+
+func (baseDialect) ReadsBlockStatements() bool {
+	return false
+}
+
+func (baseDialect) CapsRowsInQuery() bool {
+	return true
+}
+
+// QuoteName quotes a possibly schema-qualified name the way a user writes it
+// in SQL, e.g. from the command line or a FROM clause.
+func QuoteName(d Dialect, name string) (string, error) {
+	parts, err := ResolveName(d, name)
+	if err != nil {
+		return "", err
+	}
+	for i, part := range parts {
+		parts[i] = d.QuoteIdentifier(part)
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// ResolveName splits a possibly schema-qualified name into its exact parts.
+// Quoted parts ("Mixed Case" or `name`) are taken verbatim; bare parts must be
+// valid identifiers and are folded the way the engine folds unquoted names,
+// so explore users still finds USERS on oracle. Anything else is rejected
+// rather than being spliced into a query.
+func ResolveName(d Dialect, name string) ([]string, error) {
+	input := strings.TrimSpace(name)
+	rest := input
+	var parts []string
+	for {
+		var part string
+		if rest != "" && (rest[0] == '"' || rest[0] == '`') {
+			exact, after, err := readQuotedIdentifier(rest, rest[0])
+			if err != nil {
+				return nil, err
+			}
+			part, rest = exact, after
+		} else {
+			end := strings.IndexByte(rest, '.')
+			if end < 0 {
+				end = len(rest)
+			}
+			bare := rest[:end]
+			if !bareIdentifier.MatchString(bare) {
+				return nil, fmt.Errorf("invalid identifier %q in %q", bare, input)
+			}
+			part, rest = d.FoldIdentifier(bare), rest[end:]
+		}
+		parts = append(parts, part)
+		if rest == "" {
+			break
+		}
+		if rest[0] != '.' {
+			return nil, fmt.Errorf("invalid name: %q", input)
+		}
+		rest = rest[1:]
+	}
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("too many name parts: %q", input)
+	}
+	return parts, nil
+}
+
+func readQuotedIdentifier(s string, quote byte) (exact, rest string, err error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != quote {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == quote {
+			b.WriteByte(quote)
+			i++
+			continue
+		}
+		if b.Len() == 0 {
+			return "", "", fmt.Errorf("empty quoted identifier")
+		}
+		return b.String(), s[i+1:], nil
+	}
+	return "", "", fmt.Errorf("unterminated quoted identifier: %s", s)
+}
+
+// SelectAllSQL builds a row-limited SELECT * for a user supplied table name.
+func SelectAllSQL(d Dialect, tableName string, limit int) (string, error) {
+	quoted, err := QuoteName(d, tableName)
+	if err != nil {
+		return "", err
+	}
+	return d.LimitRows("SELECT * FROM "+quoted, limit), nil
+}
+
+// InsertSQL builds an INSERT for a user supplied table name with one bind
+// parameter per column, in order. Columns (exact names) left out take their
+// defaults. returning lists columns to read back; the bool reports whether
+// the engine supports it, in which case the statement yields a single row.
+func InsertSQL(d Dialect, tableName string, columns, returning []string) (string, bool, error) {
+	table, err := QuoteName(d, tableName)
+	if err != nil {
+		return "", false, err
+	}
+
+	var query string
+	if len(columns) == 0 {
+		query = d.InsertDefaults(table)
+	} else {
+		names := make([]string, len(columns))
+		params := make([]string, len(columns))
+		for i, col := range columns {
+			names[i] = d.QuoteIdentifier(col)
+			params[i] = d.Placeholder(i + 1)
+		}
+		query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(names, ", "), strings.Join(params, ", "))
+	}
+
+	if len(returning) == 0 {
+		return query, false, nil
+	}
+	quoted := make([]string, len(returning))
+	for i, col := range returning {
+		quoted[i] = d.QuoteIdentifier(col)
+	}
+	clause := d.Returning(quoted)
+	if clause == "" {
+		return query, false, nil
+	}
+	return query + " " + clause, true, nil
+}
+
+// InsertRowsSQL builds an INSERT of rows rows into a user supplied table with
+// one bind parameter per column, row after row. With keys (exact names among
+// columns) rows whose keys are already in the table are updated instead.
+func InsertRowsSQL(d Dialect, tableName string, columns, keys []string, rows int) (string, error) {
+	table, err := QuoteName(d, tableName)
+	if err != nil {
+		return "", err
+	}
+	quoted := quoteAll(d, columns)
+	params := make([][]string, rows)
+	for i := range params {
+		params[i] = make([]string, len(columns))
+		for j := range columns {
+			params[i][j] = d.Placeholder(i*len(columns) + j + 1)
+		}
+	}
+	if len(keys) == 0 {
+		return d.InsertRows(table, quoted, params), nil
+	}
+	return d.UpsertRows(table, quoted, quoteAll(d, keys), params), nil
+}
+
+// CreateTableSQL builds a CREATE TABLE for a user supplied table name whose
+// columns (exact names) hold values of the given ParamTypes.
+func CreateTableSQL(d Dialect, tableName string, columns, kinds, primaryKey []string) (string, error) {
+	table, err := QuoteName(d, tableName)
+	if err != nil {
+		return "", err
+	}
+	defs := make([]string, len(columns))
+	for i, col := range columns {
+		defs[i] = d.QuoteIdentifier(col) + " " + d.ColumnType(kinds[i])
+	}
+	if len(primaryKey) > 0 {
+		defs = append(defs, "PRIMARY KEY ("+strings.Join(quoteAll(d, primaryKey), ", ")+")")
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)", table, strings.Join(defs, ", ")), nil
+}
+
+func quoteAll(d Dialect, names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = d.QuoteIdentifier(name)
+	}
+	return quoted
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	_ "github.com/godror/godror"
+)
+
+type OracleDialect struct {
+	baseDialect
+}
+
+func (OracleDialect) Name() string       { return "oracle" }
+func (OracleDialect) DriverName() string { return "godror" }
+
+func (OracleDialect) Placeholder(index int) string {
+	return fmt.Sprintf(":%d", index)
+}
+
+func (OracleDialect) LimitRows(query string, limit int) string {
+	return fmt.Sprintf("%s FETCH FIRST %d ROWS ONLY", query, limit)
+}
+
+func (OracleDialect) FoldIdentifier(name string) string {
+	return strings.ToUpper(name)
+}
+
+func (OracleDialect) ScriptSyntax() ScriptSyntax {
+	return ScriptSyntax{SlashBlocks: true}
+}
+
+// InsertRows uses INSERT ALL, as oracle takes a single row per VALUES.
+func (OracleDialect) InsertRows(table string, columns []string, rows [][]string) string {
+	var b strings.Builder
+	b.WriteString("INSERT ALL")
+	for _, row := range rows {
+		fmt.Fprintf(&b, " INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(row, ", "))
+	}
+	b.WriteString(" SELECT 1 FROM DUAL")
+	return b.String()
+}
+
+// UpsertRows merges the rows, selected from DUAL, on keys.
+func (OracleDialect) UpsertRows(table string, columns, keys []string, rows [][]string) string {
+	selects := make([]string, len(rows))
+	for i, row := range rows {
+		values := make([]string, len(row))
+		for j, param := range row {
+			values[j] = param + " AS " + columns[j]
+		}
+		selects[i] = "SELECT " + strings.Join(values, ", ") + " FROM DUAL"
+	}
+
+	var on, set, sourced []string
+	for _, col := range columns {
+		sourced = append(sourced, "s."+col)
+		if slices.Contains(keys, col) {
+			on = append(on, fmt.Sprintf("t.%s = s.%s", col, col))
+		} else {
+			set = append(set, fmt.Sprintf("t.%s = s.%s", col, col))
+		}
+	}
+
+	query := fmt.Sprintf("MERGE INTO %s t USING (%s) s ON (%s)", table, strings.Join(selects, " UNION ALL "), strings.Join(on, " AND "))
+	if len(set) > 0 {
+		query += " WHEN MATCHED THEN UPDATE SET " + strings.Join(set, ", ")
+	}
+	return query + fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)", strings.Join(columns, ", "), strings.Join(sourced, ", "))
+}
+
+// BoolLiteral is 1 or 0, what a NUMBER(1) flag holds: oracle SQL had no
+// boolean before 23ai.
+func (OracleDialect) BoolLiteral(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// BytesLiteral goes through RAW, which takes up to 2000 bytes in SQL.
+func (OracleDialect) BytesLiteral(b []byte) string {
+	return "HEXTORAW('" + hex.EncodeToString(b) + "')"
+}
+
+// TimeLiteral is an ANSI timestamp literal, which unlike a string doesn't
+// depend on the session's NLS date format.
+func (OracleDialect) TimeLiteral(t time.Time) string {
+	layout := timeLayout
+	if t.Location() != time.UTC {
+		layout += " -07:00"
+	}
+	return "TIMESTAMP '" + t.Format(layout) + "'"
+}
+
+func (OracleDialect) ColumnType(kind string) string {
+	switch kind {
+	case "int":
+		return "NUMBER(19)"
+	case "float":
+		return "BINARY_DOUBLE"
+	case "decimal":
+		return "NUMBER"
+	case "bool":
+		return "NUMBER(1)"
+	case "date":
+		return "DATE"
+	case "timestamp":
+		return "TIMESTAMP"
+	case "bytes":
+		return "BLOB"
+	}
+	return "VARCHAR2(4000)"
+}
+
+// ColumnKind takes DATE for a timestamp: oracle dates keep the time of day.
+func (d OracleDialect) ColumnKind(typeName string) string {
+	kind := d.baseDialect.ColumnKind(typeName)
+	if kind == "date" {
+		return "timestamp"
+	}
+	return kind
+}
+
+// ReleaseSavepoint is "" for oracle, where setting a savepoint again moves it.
+func (OracleDialect) ReleaseSavepoint(name string) string {
+	return ""
+}
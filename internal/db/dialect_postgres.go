@@ -0,0 +1,43 @@
+package db
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+type PostgresDialect struct {
+	baseDialect
+}
+
+func (PostgresDialect) Name() string       { return "postgres" }
+func (PostgresDialect) DriverName() string { return "postgres" }
+
+func (PostgresDialect) Placeholder(index int) string {
+	return fmt.Sprintf("$%d", index)
+}
+
+func (PostgresDialect) ScriptSyntax() ScriptSyntax {
+	return ScriptSyntax{NestedComments: true}
+}
+
+func (PostgresDialect) FoldIdentifier(name string) string {
+	return strings.ToLower(name)
+}
+
+func (PostgresDialect) Returning(columns []string) string {
+	return "RETURNING " + strings.Join(columns, ", ")
+}
+
+func (PostgresDialect) BytesLiteral(b []byte) string {
+	return "decode('" + hex.EncodeToString(b) + "', 'hex')"
+}
+
+func (d PostgresDialect) ColumnType(kind string) string {
+	if kind == "bytes" {
+		return "BYTEA"
+	}
+	return d.baseDialect.ColumnType(kind)
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	sqliteMemory    = ":memory:"
+	sqliteURIPrefix = "file:"
+)
+
+type SQLiteDialect struct {
+	baseDialect
+}
+
+func (SQLiteDialect) Name() string       { return "sqlite3" }
+func (SQLiteDialect) DriverName() string { return "sqlite3" }
+
+func (SQLiteDialect) NormalizeConnString(connString string) (string, error) {
+	return resolveSQLitePath(connString)
+}
+
+// ConfigureDB keeps in-memory databases on a single connection, since every
+// new connection would otherwise get its own empty database.
+func (SQLiteDialect) ConfigureDB(db *sql.DB, connString string) {
+	if isSQLiteMemory(connString) {
+		db.SetMaxOpenConns(1)
+	}
+}
+
+// resolveSQLitePath turns a relative database file into an absolute one, so
+// the connection keeps pointing at the same file no matter where pam runs
+// later. URI options (file:data.db?mode=ro) are preserved; the "file:" prefix
+// is added when options are present because the driver ignores them otherwise.
+func resolveSQLitePath(connStr string) (string, error) {
+	connStr = strings.TrimSpace(connStr)
+	if connStr == "" {
+		return "", fmt.Errorf("sqlite connection needs a database file path or %s", sqliteMemory)
+	}
+
+	rest := strings.TrimPrefix(connStr, sqliteURIPrefix)
+	hasPrefix := rest != connStr
+	rest = strings.TrimPrefix(rest, "//")
+
+	path, rawQuery, hasQuery := strings.Cut(rest, "?")
+	if hasQuery {
+		if _, err := url.ParseQuery(rawQuery); err != nil {
+			return "", fmt.Errorf("invalid sqlite options %q: %w", rawQuery, err)
+		}
+	}
+
+	if path != "" && path != sqliteMemory && !isSQLiteMemory(connStr) && !filepath.IsAbs(path) {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", fmt.Errorf("resolving sqlite path %s: %w", path, err)
+		}
+		path = abs
+	}
+
+	if !hasQuery {
+		if hasPrefix {
+			return sqliteURIPrefix + path, nil
+		}
+		return path, nil
+	}
+	return sqliteURIPrefix + path + "?" + rawQuery, nil
+}
+
+// SameSQLiteFile reports whether two connection strings open the same
+// database file. In-memory databases are never shared between connections.
+func SameSQLiteFile(a, b string) bool {
+	if isSQLiteMemory(a) || isSQLiteMemory(b) {
+		return false
+	}
+	pathA, pathB := sqliteFile(a), sqliteFile(b)
+	infoA, errA := os.Stat(pathA)
+	infoB, errB := os.Stat(pathB)
+	if errA == nil && errB == nil {
+		return os.SameFile(infoA, infoB)
+	}
+	return pathA == pathB
+}
+
+// sqliteFile is the file path of a connection string, without its options.
+func sqliteFile(connStr string) string {
+	rest := strings.TrimPrefix(strings.TrimSpace(connStr), sqliteURIPrefix)
+	path, _, _ := strings.Cut(strings.TrimPrefix(rest, "//"), "?")
+	return filepath.Clean(path)
+}
+
+func isSQLiteMemory(connStr string) bool {
+	if strings.Contains(connStr, sqliteMemory) {
+		return true
+	}
+	_, rawQuery, found := strings.Cut(connStr, "?")
+	if !found {
+		return false
+	}
+	params, err := url.ParseQuery(rawQuery)
+	return err == nil && params.Get("mode") == "memory"
+}
+
+func (SQLiteDialect) Returning(columns []string) string {
+	return "RETURNING " + strings.Join(columns, ", ")
+}
+
+// ReadsBlockStatements is true for sqlite: a reader keeps writers on the file
+// waiting, and an in-memory database has only the one connection.
+func (SQLiteDialect) ReadsBlockStatements() bool {
+	return true
+}
+
+// CapsRowsInQuery is false for sqlite, which only steps through the rows
+// that are read and would rename the duplicate columns of a wrapped join.
+func (SQLiteDialect) CapsRowsInQuery() bool {
+	return false
+}
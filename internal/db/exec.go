@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// rowKeywords start statements that hand back rows.
+var rowKeywords = map[string]bool{
+	"SELECT": true, "WITH": true, "VALUES": true, "TABLE": true,
+	"SHOW": true, "EXPLAIN": true, "DESCRIBE": true, "DESC": true, "PRAGMA": true,
+}
+
+// dmlKeywords start statements whose affected row count means something;
+// drivers report 0 for DDL.
+var dmlKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "REPLACE": true,
+}
+
+var returningClause = regexp.MustCompile(`(?i)\bRETURNING\b`)
+
+// Executor runs statements: a connection, or a transaction opened on one.
+type Executor interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// ExecResult is what a statement that returns no rows reports back.
+type ExecResult struct {
+	RowsAffected int64 // -1 when the driver can't tell
+	LastInsertID int64 // 0 unless an INSERT got an id the engine reports
+	Elapsed      time.Duration
+}
+
+// Summary describes the changes, e.g. "3 rows affected, last insert id 42",
+// or "" when the driver reported nothing.
+func (r *ExecResult) Summary() string {
+	var parts []string
+	switch r.RowsAffected {
+	case -1:
+	case 1:
+		parts = append(parts, "1 row affected")
+	default:
+		parts = append(parts, fmt.Sprintf("%d rows affected", r.RowsAffected))
+	}
+	if r.LastInsertID > 0 {
+		parts = append(parts, fmt.Sprintf("last insert id %d", r.LastInsertID))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ReturnsRows guesses from its text whether a statement produces a result
+// set (SELECT, SHOW, ... or DML with RETURNING) or should be executed.
+func ReturnsRows(query string) bool {
+	return rowKeywords[firstKeyword(query)] || returningClause.MatchString(query)
+}
+
+// Exec runs a statement that returns no rows and reports what it changed.
+// Counts are only read for DML, and the last insert id only for INSERTs:
+// some engines hand back the id of an earlier insert for anything else.
+func Exec(ctx context.Context, ex Executor, query string, args ...any) (*ExecResult, error) {
+	start := time.Now()
+	result, err := ex.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &ExecResult{RowsAffected: -1, Elapsed: time.Since(start)}
+	keyword := firstKeyword(query)
+	if !dmlKeywords[keyword] {
+		return r, nil
+	}
+	if n, err := result.RowsAffected(); err == nil {
+		r.RowsAffected = n
+	}
+	if keyword == "INSERT" {
+		if id, err := result.LastInsertId(); err == nil && id > 0 {
+			r.LastInsertID = id
+		}
+	}
+	return r, nil
+}
+
+// firstKeyword returns the upper-cased first word of a statement, skipping
+// leading comments and parentheses.
+func firstKeyword(query string) string {
+	s := TrimLeadingComments(query)
+	for strings.HasPrefix(s, "(") {
+		s = TrimLeadingComments(s[1:])
+	}
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	if end < 0 {
+		end = len(s)
+	}
+	return strings.ToUpper(s[:end])
+}
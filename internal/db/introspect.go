@@ -0,0 +1,302 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTableNotFound is returned by DescribeTable for a table that isn't there.
+var ErrTableNotFound = errors.New("table not found")
+
+type TableInfo struct {
+	Schema string
+	Name   string
+	IsView bool
+}
+
+type ColumnInfo struct {
+	Name     string
+	Type     string
+	Nullable bool
+	Default  sql.NullString
+}
+
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefSchema  string
+	RefTable   string
+	RefColumns []string
+}
+
+// Constraint is a CHECK constraint, with its condition as the engine writes
+// it, or a UNIQUE constraint, with its columns.
+type Constraint struct {
+	Name    string
+	Unique  bool
+	Check   string
+	Columns []string
+}
+
+// IndexExpression stands in for an index part that is an expression rather
+// than a column.
+const IndexExpression = "<expression>"
+
+type IndexInfo struct {
+	Name    string
+	Columns []string
+	Unique  bool
+	Primary bool
+}
+
+// HasExpression reports whether part of the index is an expression, which
+// no result column can stand for.
+func (idx IndexInfo) HasExpression() bool {
+	return containsFold(idx.Columns, IndexExpression)
+}
+
+// Introspector reads schema metadata from the engine's catalog. An empty
+// schema always means the connection's current (default) schema; table names
+// are exact, as returned by Tables or ResolveName.
+type Introspector interface {
+	Schemas() ([]string, error)
+	Tables(schema string) ([]TableInfo, error)
+	Views(schema string) ([]TableInfo, error)
+	Columns(schema, table string) ([]ColumnInfo, error)
+	PrimaryKey(schema, table string) ([]string, error)
+	ForeignKeys(schema, table string) ([]ForeignKey, error)
+	Indexes(schema, table string) ([]IndexInfo, error)
+	// Constraints lists the CHECK constraints, and the UNIQUE constraints
+	// that no unique index of the same name already stands for.
+	Constraints(schema, table string) ([]Constraint, error)
+}
+
+// TableSchema is everything the catalog knows about a single table.
+type TableSchema struct {
+	Schema      string
+	Name        string
+	Columns     []ColumnInfo
+	PrimaryKey  []string
+	ForeignKeys []ForeignKey
+	Indexes     []IndexInfo
+	Constraints []Constraint
+}
+
+func NewIntrospector(conn DatabaseConnection) (Introspector, error) {
+	if conn.GetDB() == nil {
+		return nil, fmt.Errorf("database is not open")
+	}
+	return conn.GetDialect().Introspector(conn.GetDB()), nil
+}
+
+// DescribeTable resolves a table name as typed by the user and loads its
+// columns, keys, indexes and constraints.
+func DescribeTable(conn DatabaseConnection, name string) (*TableSchema, error) {
+	in, err := NewIntrospector(conn)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := ResolveName(conn.GetDialect(), name)
+	if err != nil {
+		return nil, err
+	}
+	schema, table := "", parts[len(parts)-1]
+	if len(parts) > 1 {
+		schema = parts[len(parts)-2]
+	}
+
+	ts := &TableSchema{Schema: schema, Name: table}
+	if ts.Columns, err = in.Columns(schema, table); err != nil {
+		return nil, fmt.Errorf("reading columns of %s: %w", name, err)
+	}
+	if len(ts.Columns) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, name)
+	}
+	if ts.PrimaryKey, err = in.PrimaryKey(schema, table); err != nil {
+		return nil, fmt.Errorf("reading primary key of %s: %w", name, err)
+	}
+	if ts.ForeignKeys, err = in.ForeignKeys(schema, table); err != nil {
+		return nil, fmt.Errorf("reading foreign keys of %s: %w", name, err)
+	}
+	if ts.Indexes, err = in.Indexes(schema, table); err != nil {
+		return nil, fmt.Errorf("reading indexes of %s: %w", name, err)
+	}
+	if ts.Constraints, err = in.Constraints(schema, table); err != nil {
+		return nil, fmt.Errorf("reading constraints of %s: %w", name, err)
+	}
+	return ts, nil
+}
+
+func (ts *TableSchema) Column(name string) (ColumnInfo, bool) {
+	for _, c := range ts.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return c, true
+		}
+	}
+	return ColumnInfo{}, false
+}
+
+// UniqueKeys returns the primary key followed by the columns of every unique
+// index, i.e. every column set that identifies a single row. Indexes on
+// expressions are left out: their columns alone don't identify a row.
+func (ts *TableSchema) UniqueKeys() [][]string {
+	var keys [][]string
+	if len(ts.PrimaryKey) > 0 {
+		keys = append(keys, ts.PrimaryKey)
+	}
+	for _, idx := range ts.Indexes {
+		if idx.Unique && !idx.Primary && len(idx.Columns) > 0 && !idx.HasExpression() {
+			keys = append(keys, idx.Columns)
+		}
+	}
+	return keys
+}
+
+func (ts *TableSchema) IsPrimaryKey(column string) bool {
+	return containsFold(ts.PrimaryKey, column)
+}
+
+// ForeignKeyFor returns the foreign key that column takes part in, if any.
+func (ts *TableSchema) ForeignKeyFor(column string) (ForeignKey, bool) {
+	for _, fk := range ts.ForeignKeys {
+		if containsFold(fk.Columns, column) {
+			return fk, true
+		}
+	}
+	return ForeignKey{}, false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func queryStrings(db *sql.DB, query string, args ...any) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+func queryTables(db *sql.DB, isView bool, query string, args ...any) ([]TableInfo, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []TableInfo
+	for rows.Next() {
+		t := TableInfo{IsView: isView}
+		if err := rows.Scan(&t.Schema, &t.Name); err != nil {
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, rows.Err()
+}
+
+// queryForeignKeys scans rows of (constraint, column, ref schema, ref table,
+// ref column) ordered by constraint and column position.
+func queryForeignKeys(db *sql.DB, query string, args ...any) ([]ForeignKey, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []ForeignKey
+	for rows.Next() {
+		var name, column, refTable string
+		var refSchema, refColumn sql.NullString
+		if err := rows.Scan(&name, &column, &refSchema, &refTable, &refColumn); err != nil {
+			return nil, err
+		}
+		if len(result) == 0 || result[len(result)-1].Name != name {
+			result = append(result, ForeignKey{Name: name, RefSchema: refSchema.String, RefTable: refTable})
+		}
+		fk := &result[len(result)-1]
+		fk.Columns = append(fk.Columns, column)
+		fk.RefColumns = append(fk.RefColumns, refColumn.String)
+	}
+	return result, rows.Err()
+}
+
+// queryIndexes scans rows of (index, unique, primary, column) ordered by index
+// and column position.
+func queryIndexes(db *sql.DB, query string, args ...any) ([]IndexInfo, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []IndexInfo
+	for rows.Next() {
+		var name, column string
+		var unique, primary bool
+		if err := rows.Scan(&name, &unique, &primary, &column); err != nil {
+			return nil, err
+		}
+		if len(result) == 0 || result[len(result)-1].Name != name {
+			result = append(result, IndexInfo{Name: name, Unique: unique, Primary: primary})
+		}
+		idx := &result[len(result)-1]
+		idx.Columns = append(idx.Columns, column)
+	}
+	return result, rows.Err()
+}
+
+// queryConstraints scans rows of (constraint, unique, check, column) ordered
+// by constraint and column position; a check has a single row and no column.
+func queryConstraints(db *sql.DB, query string, args ...any) ([]Constraint, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []Constraint
+	for rows.Next() {
+		var name string
+		var unique bool
+		var check, column sql.NullString
+		if err := rows.Scan(&name, &unique, &check, &column); err != nil {
+			return nil, err
+		}
+		if len(result) == 0 || result[len(result)-1].Name != name {
+			result = append(result, Constraint{Name: name, Unique: unique, Check: check.String})
+		}
+		if column.Valid {
+			c := &result[len(result)-1]
+			c.Columns = append(c.Columns, column.String)
+		}
+	}
+	return result, rows.Err()
+}
+
+func primaryKeyFromIndexes(indexes []IndexInfo) []string {
+	for _, idx := range indexes {
+		if idx.Primary {
+			return idx.Columns
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package db
+
+import "database/sql"
+
+// mysqlSchema resolves the empty schema to the database selected in the DSN.
+const mysqlSchema = "COALESCE(NULLIF(?, ''), DATABASE())"
+
+type mysqlIntrospector struct {
+	db *sql.DB
+}
+
+func (MySQLDialect) Introspector(db *sql.DB) Introspector {
+	return mysqlIntrospector{db: db}
+}
+
+func (m mysqlIntrospector) Schemas() ([]string, error) {
+	return queryStrings(m.db, `
+		SELECT schema_name FROM information_schema.schemata
+		WHERE schema_name NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')
+		ORDER BY schema_name`)
+}
+
+func (m mysqlIntrospector) Tables(schema string) ([]TableInfo, error) {
+	return m.relations(schema, "BASE TABLE", false)
+}
+
+func (m mysqlIntrospector) Views(schema string) ([]TableInfo, error) {
+	return m.relations(schema, "VIEW", true)
+}
+
+func (m mysqlIntrospector) relations(schema, tableType string, isView bool) ([]TableInfo, error) {
+	return queryTables(m.db, isView, `
+		SELECT table_schema, table_name FROM information_schema.tables
+		WHERE table_schema = `+mysqlSchema+` AND table_type = ?
+		ORDER BY table_name`, schema, tableType)
+}
+
+func (m mysqlIntrospector) Columns(schema, table string) ([]ColumnInfo, error) {
+	rows, err := m.db.Query(`
+		SELECT column_name, column_type, is_nullable = 'YES', column_default
+		FROM information_schema.columns
+		WHERE table_schema = `+mysqlSchema+` AND table_name = ?
+		ORDER BY ordinal_position`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var c ColumnInfo
+		if err := rows.Scan(&c.Name, &c.Type, &c.Nullable, &c.Default); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+func (m mysqlIntrospector) PrimaryKey(schema, table string) ([]string, error) {
+	indexes, err := m.Indexes(schema, table)
+	if err != nil {
+		return nil, err
+	}
+	return primaryKeyFromIndexes(indexes), nil
+}
+
+func (m mysqlIntrospector) ForeignKeys(schema, table string) ([]ForeignKey, error) {
+	return queryForeignKeys(m.db, `
+		SELECT constraint_name, column_name, referenced_table_schema,
+		       referenced_table_name, referenced_column_name
+		FROM information_schema.key_column_usage
+		WHERE table_schema = `+mysqlSchema+` AND table_name = ?
+		  AND referenced_table_name IS NOT NULL
+		ORDER BY constraint_name, ordinal_position`, schema, table)
+}
+
+// Indexes lists the indexes of a table. The parts of a functional index
+// (MySQL 8) have no column and show as IndexExpression.
+func (m mysqlIntrospector) Indexes(schema, table string) ([]IndexInfo, error) {
+	return queryIndexes(m.db, `
+		SELECT index_name, non_unique = 0, index_name = 'PRIMARY', COALESCE(column_name, '`+IndexExpression+`')
+		FROM information_schema.statistics
+		WHERE table_schema = `+mysqlSchema+` AND table_name = ?
+		ORDER BY index_name = 'PRIMARY' DESC, index_name, seq_in_index`, schema, table)
+}
+
+// Constraints lists the CHECK constraints, which MySQL enforces from 8.0.16
+// and MariaDB from 10.2; older servers have no table of them. Every UNIQUE
+// constraint is an index of its name.
+func (m mysqlIntrospector) Constraints(schema, table string) ([]Constraint, error) {
+	var hasChecks bool
+	if err := m.db.QueryRow(`
+		SELECT COUNT(*) > 0 FROM information_schema.tables
+		WHERE table_schema = 'information_schema' AND table_name = 'CHECK_CONSTRAINTS'`).Scan(&hasChecks); err != nil || !hasChecks {
+		return nil, err
+	}
+	return queryConstraints(m.db, `
+		SELECT tc.constraint_name, false, cc.check_clause, NULL
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.check_constraints cc
+		  ON cc.constraint_schema = tc.constraint_schema AND cc.constraint_name = tc.constraint_name
+		WHERE tc.constraint_type = 'CHECK'
+		  AND tc.table_schema = `+mysqlSchema+` AND tc.table_name = ?
+		ORDER BY tc.constraint_name`, schema, table)
+}
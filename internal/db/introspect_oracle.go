@@ -0,0 +1,143 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// oracleOwner resolves the empty schema to the session's current schema;
+// oracle treats '' as NULL so NVL handles it.
+const oracleOwner = "NVL(:1, SYS_CONTEXT('USERENV', 'CURRENT_SCHEMA'))"
+
+type oracleIntrospector struct {
+	db *sql.DB
+}
+
+func (OracleDialect) Introspector(db *sql.DB) Introspector {
+	return oracleIntrospector{db: db}
+}
+
+func (o oracleIntrospector) Schemas() ([]string, error) {
+	return queryStrings(o.db, `
+		SELECT username FROM all_users
+		WHERE oracle_maintained = 'N'
+		ORDER BY username`)
+}
+
+func (o oracleIntrospector) Tables(schema string) ([]TableInfo, error) {
+	return queryTables(o.db, false, `
+		SELECT owner, table_name FROM all_tables
+		WHERE owner = `+oracleOwner+`
+		ORDER BY table_name`, schema)
+}
+
+func (o oracleIntrospector) Views(schema string) ([]TableInfo, error) {
+	return queryTables(o.db, true, `
+		SELECT owner, view_name FROM all_views
+		WHERE owner = `+oracleOwner+`
+		ORDER BY view_name`, schema)
+}
+
+func (o oracleIntrospector) Columns(schema, table string) ([]ColumnInfo, error) {
+	rows, err := o.db.Query(`
+		SELECT column_name, data_type, char_length, data_precision, data_scale,
+		       nullable, data_default
+		FROM all_tab_columns
+		WHERE owner = `+oracleOwner+` AND table_name = :2
+		ORDER BY column_id`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var c ColumnInfo
+		var charLength, precision, scale sql.NullInt64
+		var nullable string
+		if err := rows.Scan(&c.Name, &c.Type, &charLength, &precision, &scale, &nullable, &c.Default); err != nil {
+			return nil, err
+		}
+		c.Type = oracleColumnType(c.Type, charLength, precision, scale)
+		c.Nullable = nullable == "Y"
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+func oracleColumnType(dataType string, charLength, precision, scale sql.NullInt64) string {
+	switch {
+	case precision.Valid && scale.Valid && scale.Int64 > 0:
+		return fmt.Sprintf("%s(%d,%d)", dataType, precision.Int64, scale.Int64)
+	case precision.Valid:
+		return fmt.Sprintf("%s(%d)", dataType, precision.Int64)
+	case charLength.Valid && charLength.Int64 > 0:
+		return fmt.Sprintf("%s(%d)", dataType, charLength.Int64)
+	default:
+		return dataType
+	}
+}
+
+func (o oracleIntrospector) PrimaryKey(schema, table string) ([]string, error) {
+	return queryStrings(o.db, `
+		SELECT cols.column_name
+		FROM all_constraints c
+		JOIN all_cons_columns cols
+		  ON cols.owner = c.owner AND cols.constraint_name = c.constraint_name
+		WHERE c.constraint_type = 'P' AND c.owner = `+oracleOwner+` AND c.table_name = :2
+		ORDER BY cols.position`, schema, table)
+}
+
+func (o oracleIntrospector) ForeignKeys(schema, table string) ([]ForeignKey, error) {
+	return queryForeignKeys(o.db, `
+		SELECT c.constraint_name, cols.column_name, r.owner, r.table_name, rcols.column_name
+		FROM all_constraints c
+		JOIN all_cons_columns cols
+		  ON cols.owner = c.owner AND cols.constraint_name = c.constraint_name
+		JOIN all_constraints r
+		  ON r.owner = c.r_owner AND r.constraint_name = c.r_constraint_name
+		JOIN all_cons_columns rcols
+		  ON rcols.owner = r.owner AND rcols.constraint_name = r.constraint_name
+		 AND rcols.position = cols.position
+		WHERE c.constraint_type = 'R' AND c.owner = `+oracleOwner+` AND c.table_name = :2
+		ORDER BY c.constraint_name, cols.position`, schema, table)
+}
+
+// Constraints lists the CHECK constraints but the NOT NULL ones oracle
+// makes for columns, and the UNIQUE constraints that are enforced by an
+// index of another name, a non-unique one or none (when disabled).
+func (o oracleIntrospector) Constraints(schema, table string) ([]Constraint, error) {
+	return queryConstraints(o.db, `
+		SELECT c.constraint_name,
+		       CASE WHEN c.constraint_type = 'U' THEN 1 ELSE 0 END,
+		       c.search_condition_vc,
+		       cols.column_name
+		FROM all_constraints c
+		LEFT JOIN all_cons_columns cols
+		  ON c.constraint_type = 'U'
+		 AND cols.owner = c.owner AND cols.constraint_name = c.constraint_name
+		LEFT JOIN all_indexes i
+		  ON i.owner = c.index_owner AND i.index_name = c.index_name
+		WHERE c.owner = `+oracleOwner+` AND c.table_name = :2
+		  AND (c.constraint_type = 'C'
+		       AND NOT (c.generated = 'GENERATED NAME' AND c.search_condition_vc LIKE '"%" IS NOT NULL')
+		    OR c.constraint_type = 'U'
+		       AND (i.index_name IS NULL OR i.uniqueness <> 'UNIQUE' OR i.index_name <> c.constraint_name))
+		ORDER BY c.constraint_name, cols.position`, schema, table)
+}
+
+func (o oracleIntrospector) Indexes(schema, table string) ([]IndexInfo, error) {
+	return queryIndexes(o.db, `
+		SELECT i.index_name,
+		       CASE WHEN i.uniqueness = 'UNIQUE' THEN 1 ELSE 0 END,
+		       CASE WHEN pk.constraint_name IS NOT NULL THEN 1 ELSE 0 END,
+		       ic.column_name
+		FROM all_indexes i
+		JOIN all_ind_columns ic
+		  ON ic.index_owner = i.owner AND ic.index_name = i.index_name
+		LEFT JOIN all_constraints pk
+		  ON pk.owner = i.table_owner AND pk.table_name = i.table_name
+		 AND pk.constraint_type = 'P' AND pk.index_name = i.index_name
+		WHERE i.table_owner = `+oracleOwner+` AND i.table_name = :2
+		ORDER BY 3 DESC, i.index_name, ic.column_position`, schema, table)
+}
@@ -0,0 +1,113 @@
+package db
+
+import "database/sql"
+
+// pgSchema resolves the empty schema to the connection's current schema.
+const pgSchema = "COALESCE(NULLIF($1, ''), current_schema())"
+
+type postgresIntrospector struct {
+	db *sql.DB
+}
+
+func (PostgresDialect) Introspector(db *sql.DB) Introspector {
+	return postgresIntrospector{db: db}
+}
+
+func (p postgresIntrospector) Schemas() ([]string, error) {
+	return queryStrings(p.db, `
+		SELECT nspname FROM pg_namespace
+		WHERE nspname NOT IN ('pg_catalog', 'information_schema')
+		  AND nspname NOT LIKE 'pg_toast%' AND nspname NOT LIKE 'pg_temp%'
+		ORDER BY nspname`)
+}
+
+func (p postgresIntrospector) Tables(schema string) ([]TableInfo, error) {
+	return queryTables(p.db, false, `
+		SELECT schemaname, tablename FROM pg_tables
+		WHERE schemaname = `+pgSchema+`
+		ORDER BY tablename`, schema)
+}
+
+func (p postgresIntrospector) Views(schema string) ([]TableInfo, error) {
+	return queryTables(p.db, true, `
+		SELECT schemaname, viewname FROM pg_views
+		WHERE schemaname = `+pgSchema+`
+		ORDER BY viewname`, schema)
+}
+
+func (p postgresIntrospector) Columns(schema, table string) ([]ColumnInfo, error) {
+	rows, err := p.db.Query(`
+		SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+		       pg_get_expr(d.adbin, d.adrelid)
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE n.nspname = `+pgSchema+` AND c.relname = $2
+		  AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var c ColumnInfo
+		if err := rows.Scan(&c.Name, &c.Type, &c.Nullable, &c.Default); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+func (p postgresIntrospector) PrimaryKey(schema, table string) ([]string, error) {
+	indexes, err := p.Indexes(schema, table)
+	if err != nil {
+		return nil, err
+	}
+	return primaryKeyFromIndexes(indexes), nil
+}
+
+func (p postgresIntrospector) ForeignKeys(schema, table string) ([]ForeignKey, error) {
+	return queryForeignKeys(p.db, `
+		SELECT con.conname, a.attname, rn.nspname, rc.relname, ra.attname
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_class rc ON rc.oid = con.confrelid
+		JOIN pg_namespace rn ON rn.oid = rc.relnamespace
+		CROSS JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(col, refcol, ord)
+		JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.col
+		JOIN pg_attribute ra ON ra.attrelid = con.confrelid AND ra.attnum = k.refcol
+		WHERE con.contype = 'f' AND n.nspname = `+pgSchema+` AND c.relname = $2
+		ORDER BY con.conname, k.ord`, schema, table)
+}
+
+// Indexes lists the indexes of a table. Expression parts have attnum 0 and
+// show as IndexExpression.
+func (p postgresIntrospector) Indexes(schema, table string) ([]IndexInfo, error) {
+	return queryIndexes(p.db, `
+		SELECT i.relname, ix.indisunique, ix.indisprimary, COALESCE(a.attname, '`+IndexExpression+`')
+		FROM pg_index ix
+		JOIN pg_class t ON t.oid = ix.indrelid
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		CROSS JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord)
+		LEFT JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+		WHERE n.nspname = `+pgSchema+` AND t.relname = $2
+		ORDER BY ix.indisprimary DESC, i.relname, k.ord`, schema, table)
+}
+
+// Constraints lists the CHECK constraints: every UNIQUE constraint has an
+// index of its name.
+func (p postgresIntrospector) Constraints(schema, table string) ([]Constraint, error) {
+	return queryConstraints(p.db, `
+		SELECT con.conname, false, pg_get_expr(con.conbin, con.conrelid), NULL
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE con.contype = 'c' AND n.nspname = `+pgSchema+` AND c.relname = $2
+		ORDER BY con.conname`, schema, table)
+}
@@ -0,0 +1,208 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type sqliteIntrospector struct {
+	db *sql.DB
+}
+
+func (SQLiteDialect) Introspector(db *sql.DB) Introspector {
+	return sqliteIntrospector{db: db}
+}
+
+func sqliteSchema(schema string) string {
+	if schema == "" {
+		return "main"
+	}
+	return schema
+}
+
+func (s sqliteIntrospector) Schemas() ([]string, error) {
+	return queryStrings(s.db, "SELECT name FROM pragma_database_list ORDER BY seq")
+}
+
+func (s sqliteIntrospector) Tables(schema string) ([]TableInfo, error) {
+	return s.relations(schema, "table", false)
+}
+
+func (s sqliteIntrospector) Views(schema string) ([]TableInfo, error) {
+	return s.relations(schema, "view", true)
+}
+
+func (s sqliteIntrospector) relations(schema, kind string, isView bool) ([]TableInfo, error) {
+	schema = sqliteSchema(schema)
+	query := fmt.Sprintf(`
+		SELECT ?, name FROM %s.sqlite_master
+		WHERE type = ? AND name NOT LIKE 'sqlite_%%'
+		ORDER BY name`, SQLiteDialect{}.QuoteIdentifier(schema))
+	return queryTables(s.db, isView, query, schema, kind)
+}
+
+func (s sqliteIntrospector) Columns(schema, table string) ([]ColumnInfo, error) {
+	rows, err := s.db.Query(`
+		SELECT name, type, "notnull" = 0, dflt_value
+		FROM pragma_table_info(?, ?)
+		ORDER BY cid`, table, sqliteSchema(schema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var c ColumnInfo
+		if err := rows.Scan(&c.Name, &c.Type, &c.Nullable, &c.Default); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+func (s sqliteIntrospector) PrimaryKey(schema, table string) ([]string, error) {
+	return queryStrings(s.db, `
+		SELECT name FROM pragma_table_info(?, ?)
+		WHERE pk > 0
+		ORDER BY pk`, table, sqliteSchema(schema))
+}
+
+// ForeignKeys reports the referenced table's primary key when a foreign key
+// omits the referenced columns.
+func (s sqliteIntrospector) ForeignKeys(schema, table string) ([]ForeignKey, error) {
+	schema = sqliteSchema(schema)
+	fks, err := queryForeignKeys(s.db, `
+		SELECT 'fk_' || id, "from", ?, "table", "to"
+		FROM pragma_foreign_key_list(?, ?)
+		ORDER BY id, seq`, schema, table, schema)
+	if err != nil {
+		return nil, err
+	}
+	for i, fk := range fks {
+		if fk.RefColumns[0] != "" {
+			continue
+		}
+		pk, err := s.PrimaryKey(schema, fk.RefTable)
+		if err != nil {
+			return nil, err
+		}
+		if len(pk) == len(fk.Columns) {
+			fks[i].RefColumns = pk
+		}
+	}
+	return fks, nil
+}
+
+// Indexes lists the indexes of a table; the parts of an index on an
+// expression have no name and show as IndexExpression.
+func (s sqliteIntrospector) Indexes(schema, table string) ([]IndexInfo, error) {
+	return queryIndexes(s.db, `
+		SELECT il.name, il."unique", il.origin = 'pk', COALESCE(ii.name, '`+IndexExpression+`')
+		FROM pragma_index_list(?, ?) AS il
+		JOIN pragma_index_info(il.name, ?) AS ii
+		ORDER BY il.origin = 'pk' DESC, il.name, ii.seqno`, table, sqliteSchema(schema), sqliteSchema(schema))
+}
+
+// Constraints lists the CHECK constraints, read from the table's CREATE
+// TABLE statement as sqlite keeps no catalog of them. UNIQUE constraints
+// are all backed by an index.
+func (s sqliteIntrospector) Constraints(schema, table string) ([]Constraint, error) {
+	schema = sqliteSchema(schema)
+	var createSQL sql.NullString
+	err := s.db.QueryRow(fmt.Sprintf(`
+		SELECT sql FROM %s.sqlite_master
+		WHERE type = 'table' AND name = ?`, SQLiteDialect{}.QuoteIdentifier(schema)), table).Scan(&createSQL)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return sqliteChecks(createSQL.String), nil
+}
+
+// sqliteChecks finds the CHECK clauses of a CREATE TABLE statement. A check
+// without a CONSTRAINT name is called check_<n>, n counting from 1.
+func sqliteChecks(createSQL string) []Constraint {
+	var checks []Constraint
+	// The last two tokens, for CONSTRAINT <name> CHECK; quoted ones aren't
+	// keywords
+	var tokens [2]string
+	var quoted [2]bool
+	push := func(token string, isQuoted bool) {
+		tokens[0], quoted[0] = tokens[1], quoted[1]
+		tokens[1], quoted[1] = token, isQuoted
+	}
+
+	s := createSQL
+	for i := 0; i < len(s); {
+		c := s[i]
+		n := literalLength(s, i, ScriptSyntax{})
+		switch {
+		case c == '[':
+			if n = strings.IndexByte(s[i:], ']') + 1; n == 0 {
+				n = len(s) - i
+			}
+			push(strings.Trim(s[i:i+n], "[]"), true)
+		case c == '"' || c == '`':
+			push(strings.Trim(s[i:i+n], "\"`"), true)
+		case c == '\'':
+			push("", true)
+		case n > 0:
+			// A comment
+		case isIdentChar(c):
+			for n = 1; i+n < len(s) && isIdentChar(s[i+n]); n++ {
+			}
+			if !strings.EqualFold(s[i:i+n], "CHECK") {
+				push(s[i:i+n], false)
+				break
+			}
+			body, end, ok := parenthesized(s, i+n)
+			if !ok {
+				push(s[i:i+n], false)
+				break
+			}
+			name := fmt.Sprintf("check_%d", len(checks)+1)
+			if !quoted[0] && strings.EqualFold(tokens[0], "CONSTRAINT") {
+				name = tokens[1]
+			}
+			checks = append(checks, Constraint{Name: name, Check: body})
+			push("", true)
+			n = end - i
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			n = 1
+		default:
+			push(string(c), true)
+			n = 1
+		}
+		i += n
+	}
+	return checks
+}
+
+// parenthesized returns what is between the parenthesis that opens after
+// the blanks at s[i] and the one that closes it, and where it ends.
+func parenthesized(s string, i int) (body string, end int, ok bool) {
+	for i < len(s) && strings.ContainsRune(" \t\r\n", rune(s[i])) {
+		i++
+	}
+	if i == len(s) || s[i] != '(' {
+		return "", i, false
+	}
+	depth := 0
+	for j := i; j < len(s); j++ {
+		if n := literalLength(s, j, ScriptSyntax{}); n > 0 {
+			j += n - 1
+			continue
+		}
+		switch s[j] {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return strings.TrimSpace(s[i+1 : j]), j + 1, true
+			}
+		}
+	}
+	return "", len(s), false
+}
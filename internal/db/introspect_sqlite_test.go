@@ -0,0 +1,29 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSQLiteChecks(t *testing.T) {
+	tests := []struct {
+		name      string
+		createSQL string
+		want      []Constraint
+	}{
+		{"none", "CREATE TABLE t (a int, b text UNIQUE)", nil},
+		{"column", "CREATE TABLE t (a int CHECK (a > 0), b int check(b < a))", []Constraint{{Name: "check_1", Check: "a > 0"}, {Name: "check_2", Check: "b < a"}}},
+		{"named", "CREATE TABLE t (a int, CONSTRAINT positive CHECK ((a) > 0))", []Constraint{{Name: "positive", Check: "(a) > 0"}}},
+		{"quoted name", `CREATE TABLE t (a int CONSTRAINT "a ok" CHECK (a IN (1, 2)))`, []Constraint{{Name: "a ok", Check: "a IN (1, 2)"}}},
+		{"named other constraint", "CREATE TABLE t (a int CONSTRAINT u UNIQUE CHECK (a <> 0))", []Constraint{{Name: "check_1", Check: "a <> 0"}}},
+		{"strings and comments", "CREATE TABLE t (a text CHECK (a <> ')' /* ) */), -- CHECK (x)\n b text DEFAULT 'CHECK (y)')", []Constraint{{Name: "check_1", Check: "a <> ')' /* ) */"}}},
+		{"column named check", `CREATE TABLE t ("check" int, [check] int CHECK ("check" > 0))`, []Constraint{{Name: "check_1", Check: `"check" > 0`}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sqliteChecks(tt.createSQL); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sqliteChecks(%q) = %+v, want %+v", tt.createSQL, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUniqueKeys(t *testing.T) {
+	ts := TableSchema{
+		PrimaryKey: []string{"id"},
+		Indexes: []IndexInfo{
+			{Name: "pk", Columns: []string{"id"}, Unique: true, Primary: true},
+			{Name: "by_email", Columns: []string{"tenant_id", IndexExpression}, Unique: true},
+			{Name: "by_code", Columns: []string{"tenant_id", "code"}, Unique: true},
+			{Name: "by_name", Columns: []string{"name"}},
+		},
+	}
+	want := [][]string{{"id"}, {"tenant_id", "code"}}
+	if got := ts.UniqueKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("UniqueKeys() = %q, want %q", got, want)
+	}
+}
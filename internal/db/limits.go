@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Limits guard a connection against runaway statements. Zero values mean no
+// limit.
+type Limits struct {
+	QueryTimeout   time.Duration
+	ConnectTimeout time.Duration
+	RowLimit       int
+}
+
+// WithQueryTimeout bounds ctx by the connection's query timeout. The context
+// has to outlive the rows of the statement, so the caller cancels it once
+// they are read. disarm stops the clock without cancelling, for results whose
+// first rows are in and whose rest is read at the user's pace.
+func WithQueryTimeout(ctx context.Context, conn DatabaseConnection) (_ context.Context, cancel context.CancelFunc, disarm func()) {
+	ctx, cancelCause := context.WithCancelCause(ctx)
+	cancel = func() { cancelCause(context.Canceled) }
+
+	timeout := conn.GetLimits().QueryTimeout
+	if timeout <= 0 {
+		return ctx, cancel, func() {}
+	}
+	timer := time.AfterFunc(timeout, func() {
+		cancelCause(&TimeoutError{Timeout: timeout})
+	})
+	stop := func() { timer.Stop() }
+	return ctx, func() {
+		stop()
+		cancelCause(context.Canceled)
+	}, stop
+}
+
+// TimeoutError is the cause of a statement context cancelled by the query
+// timeout.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("exceeded query_timeout of %s", e.Timeout)
+}
+
+// TimedOut reports whether ctx was cancelled by the query timeout.
+func TimedOut(ctx context.Context) bool {
+	var timeout *TimeoutError
+	return errors.As(context.Cause(ctx), &timeout)
+}
+
+// CapRows wraps a single SELECT so the server returns at most limit+1 rows;
+// the extra row tells the reader that the result was truncated. Anything
+// else (CTEs, PRAGMA, SHOW, DML) is returned unchanged and only capped while
+// reading, as is everything on an engine that doesn't CapsRowsInQuery.
+func CapRows(d Dialect, query string, limit int) string {
+	if limit <= 0 || !d.CapsRowsInQuery() || firstKeyword(query) != "SELECT" {
+		return query
+	}
+	trimmed := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(query), ";"))
+	if strings.Contains(trimmed, ";") {
+		return query
+	}
+	// The newline ends a trailing line comment before the wrapper closes
+	return d.LimitRows("SELECT * FROM ("+trimmed+"\n) pam_capped", limit+1)
+}
+
+// QueryCapped runs a statement capped by CapRows. A SELECT that can't be
+// wrapped (mysql and oracle refuse duplicate column names in a subquery,
+// oracle a FOR UPDATE) runs as written and is only capped while reading.
+// In a transaction the first error is kept, as postgres aborts the
+// transaction on it and the retry would only report that.
+func QueryCapped(ctx context.Context, ex Executor, d Dialect, query string, limit int, args ...any) (*sql.Rows, error) {
+	capped := CapRows(d, query, limit)
+	rows, err := ex.QueryContext(ctx, capped, args...)
+	if err == nil || capped == query || ctx.Err() != nil {
+		return rows, err
+	}
+	rows, retryErr := ex.QueryContext(ctx, query, args...)
+	if _, inTx := ex.(*sql.Tx); retryErr != nil && inTx {
+		return nil, err
+	}
+	return rows, retryErr
+}
@@ -0,0 +1,30 @@
+package db
+
+import "testing"
+
+func TestCapRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		query   string
+		limit   int
+		want    string
+	}{
+		{"select", PostgresDialect{}, "SELECT * FROM t;", 10, "SELECT * FROM (SELECT * FROM t\n) pam_capped LIMIT 11"},
+		{"oracle", OracleDialect{}, "SELECT * FROM t", 10, "SELECT * FROM (SELECT * FROM t\n) pam_capped FETCH FIRST 11 ROWS ONLY"},
+		{"trailing comment", MySQLDialect{}, "SELECT 1 -- one", 1, "SELECT * FROM (SELECT 1 -- one\n) pam_capped LIMIT 2"},
+		{"leading comment", PostgresDialect{}, "-- q\nSELECT 1", 1, "SELECT * FROM (-- q\nSELECT 1\n) pam_capped LIMIT 2"},
+		{"no limit", PostgresDialect{}, "SELECT 1", 0, "SELECT 1"},
+		{"cte", PostgresDialect{}, "WITH a AS (SELECT 1) SELECT * FROM a", 10, "WITH a AS (SELECT 1) SELECT * FROM a"},
+		{"show", MySQLDialect{}, "SHOW TABLES", 10, "SHOW TABLES"},
+		{"two statements", PostgresDialect{}, "SELECT 1; SELECT 2", 10, "SELECT 1; SELECT 2"},
+		{"sqlite", SQLiteDialect{}, "SELECT 1", 10, "SELECT 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CapRows(tt.dialect, tt.query, tt.limit); got != tt.want {
+				t.Errorf("CapRows(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
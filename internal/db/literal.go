@@ -0,0 +1,74 @@
+package db
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Literal writes a scanned value as a SQL literal of the dialect, for
+// statements that are shown or saved rather than bound (sql-insert output).
+// kind is the column's kind (see ColumnKind), which tells binary data from
+// text when the driver scans both as bytes; a []byte that isn't UTF-8 is
+// binary whatever the column. NaN and infinities have no SQL literal and are
+// written as the strings postgres reads into a float.
+func Literal(d Dialect, v any, kind string) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		return d.BoolLiteral(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "'NaN'"
+		case math.IsInf(v, 1):
+			return "'Infinity'"
+		case math.IsInf(v, -1):
+			return "'-Infinity'"
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return d.TimeLiteral(v)
+	case []byte:
+		if kind == "bytes" || !utf8.Valid(v) {
+			return d.BytesLiteral(v)
+		}
+		return quoteString(d, string(v))
+	case string:
+		return quoteString(d, v)
+	}
+	return quoteString(d, fmt.Sprintf("%v", v))
+}
+
+// quoteString writes s as a string literal of the dialect.
+func quoteString(d Dialect, s string) string {
+	if d.ScriptSyntax().BackslashEscapes {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func (baseDialect) BoolLiteral(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// BytesLiteral is the X'..' hex literal of sqlite and mysql.
+func (baseDialect) BytesLiteral(b []byte) string {
+	return "X'" + hex.EncodeToString(b) + "'"
+}
+
+// TimeLiteral is a quoted string, which the engines convert to the type of
+// the column it is written into.
+func (baseDialect) TimeLiteral(t time.Time) string {
+	return "'" + formatTime(t) + "'"
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// ParamTypes are the types a parameter can declare; values are converted
+// before they are bound.
+var ParamTypes = []string{"string", "int", "float", "bool", "date"}
+
+// Param declares a named parameter of a saved query, written :name in its
+// SQL. Parameters used in the SQL but not declared are strings without a
+// default.
+type Param struct {
+	Name    string  `yaml:"name"`
+	Type    string  `yaml:"type,omitempty"`
+	Default *string `yaml:"default,omitempty"`
+}
+
+// Convert turns a value typed by the user into the parameter's type.
+func (p Param) Convert(value string) (any, error) {
+	switch p.Type {
+	case "", "string":
+		return value, nil
+	case "int":
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf(":%s wants an int, got %q", p.Name, value)
+		}
+		return n, nil
+	case "float":
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf(":%s wants a float, got %q", p.Name, value)
+		}
+		return f, nil
+	case "bool":
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf(":%s wants a bool (true/false), got %q", p.Name, value)
+		}
+		return b, nil
+	case "date":
+		t, err := time.Parse(dateLayout, strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf(":%s wants a date (YYYY-MM-DD), got %q", p.Name, value)
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf(":%s has unknown type %q (use %s)", p.Name, p.Type, strings.Join(ParamTypes, ", "))
+}
+
+// QueryParams lists the parameters a query uses, in order of first use,
+// with the declared type and default where there is one.
+func QueryParams(d Dialect, q Query) []Param {
+	var params []Param
+	for _, name := range ParamNames(d, q.SQL) {
+		param := Param{Name: name}
+		if i := slices.IndexFunc(q.Params, func(p Param) bool { return p.Name == name }); i >= 0 {
+			param = q.Params[i]
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// ParamNames returns the :name placeholders of a statement in order of first
+// use. Strings, comments, casts (a::int) and assignments (:=) are skipped.
+func ParamNames(d Dialect, query string) []string {
+	var names []string
+	scanParams(d.ScriptSyntax(), query, func(name string, start, end int) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	})
+	return names
+}
+
+// BindParams replaces every :name placeholder with the dialect's bind
+// parameter and returns the values in placeholder order, converted to their
+// declared types. Values are never spliced into the SQL.
+func BindParams(d Dialect, query string, params []Param, values map[string]string) (string, []any, error) {
+	converted := make(map[string]any, len(params))
+	for _, p := range params {
+		value, ok := values[p.Name]
+		if !ok && p.Default != nil {
+			value, ok = *p.Default, true
+		}
+		if !ok {
+			return "", nil, fmt.Errorf("missing value for :%s", p.Name)
+		}
+		v, err := p.Convert(value)
+		if err != nil {
+			return "", nil, err
+		}
+		converted[p.Name] = v
+	}
+
+	var b strings.Builder
+	var args []any
+	last := 0
+	scanParams(d.ScriptSyntax(), query, func(name string, start, end int) {
+		args = append(args, converted[name])
+		b.WriteString(query[last:start])
+		b.WriteString(d.Placeholder(len(args)))
+		last = end
+	})
+	b.WriteString(query[last:])
+	return b.String(), args, nil
+}
+
+// scanParams calls found for every :name placeholder outside of literals,
+// with its position in query.
+func scanParams(syntax ScriptSyntax, query string, found func(name string, start, end int)) {
+	for i := 0; i < len(query); i++ {
+		if n := literalLength(query, i, syntax); n > 0 {
+			i += n - 1
+			continue
+		}
+		if query[i] != ':' || i+1 >= len(query) || !isParamStart(query[i+1]) {
+			continue
+		}
+		if i > 0 && (query[i-1] == ':' || isIdentChar(query[i-1])) {
+			continue
+		}
+		end := i + 1
+		for end < len(query) && isIdentChar(query[end]) && query[end] != '$' && query[end] != '#' {
+			end++
+		}
+		found(query[i+1:end], i, end)
+		i = end - 1
+	}
+}
+
+func isParamStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParamNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		query   string
+		want    []string
+	}{
+		{"plain", PostgresDialect{}, "SELECT * FROM t WHERE id = :id", []string{"id"}},
+		{"first use order", PostgresDialect{}, "SELECT :b, :a, :b", []string{"b", "a"}},
+		{"underscore start", PostgresDialect{}, "SELECT :_x1", []string{"_x1"}},
+		{"cast", PostgresDialect{}, "SELECT a::int, :b::text FROM t", []string{"b"}},
+		{"double cast", PostgresDialect{}, "SELECT a::text::int", nil},
+		{"assignment", OracleDialect{}, "BEGIN x := :val; END;", []string{"val"}},
+		{"numeric", PostgresDialect{}, "SELECT :1", nil},
+		{"after identifier", PostgresDialect{}, "SELECT arr[lo:hi], x:y", nil},
+		{"array slice", PostgresDialect{}, "SELECT arr[1:2]", nil},
+		{"single quoted", PostgresDialect{}, "SELECT ':no', :yes", []string{"yes"}},
+		{"doubled quote", PostgresDialect{}, "SELECT 'it''s :no', :yes", []string{"yes"}},
+		{"quoted identifier", PostgresDialect{}, `SELECT ":no", :yes`, []string{"yes"}},
+		{"backquoted identifier", MySQLDialect{}, "SELECT `:no`, :yes", []string{"yes"}},
+		{"line comment", PostgresDialect{}, "-- :no\nSELECT :yes", []string{"yes"}},
+		{"block comment", PostgresDialect{}, "SELECT /* :no */ :yes", []string{"yes"}},
+		{"dollar quoted", PostgresDialect{}, "SELECT $$ :no $$, $f$ :no $f$, :yes", []string{"yes"}},
+		{"escape string", PostgresDialect{}, `SELECT E'a\' :no', :yes`, []string{"yes"}},
+		{"mysql backslash", MySQLDialect{}, `SELECT 'a\' :no', :yes`, []string{"yes"}},
+		{"unterminated string", PostgresDialect{}, "SELECT :yes, ':no", []string{"yes"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParamNames(tt.dialect, tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParamNames(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	ten := "10"
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		query    string
+		declared []Param
+		values   map[string]string
+		wantSQL  string
+		wantArgs []any
+		wantErr  bool
+	}{
+		{
+			name:     "postgres numbers every use",
+			dialect:  PostgresDialect{},
+			query:    "SELECT :a, :b, :a",
+			values:   map[string]string{"a": "x", "b": "y"},
+			wantSQL:  "SELECT $1, $2, $3",
+			wantArgs: []any{"x", "y", "x"},
+		},
+		{
+			name:     "oracle",
+			dialect:  OracleDialect{},
+			query:    "SELECT * FROM t WHERE a = :a",
+			values:   map[string]string{"a": "x"},
+			wantSQL:  "SELECT * FROM t WHERE a = :1",
+			wantArgs: []any{"x"},
+		},
+		{
+			name:     "sqlite",
+			dialect:  SQLiteDialect{},
+			query:    "SELECT :a",
+			values:   map[string]string{"a": "x"},
+			wantSQL:  "SELECT ?",
+			wantArgs: []any{"x"},
+		},
+		{
+			name:     "literals left alone",
+			dialect:  PostgresDialect{},
+			query:    "SELECT ':a', a::int, /* :a */ :a -- :a",
+			values:   map[string]string{"a": "x"},
+			wantSQL:  "SELECT ':a', a::int, /* :a */ $1 -- :a",
+			wantArgs: []any{"x"},
+		},
+		{
+			name:     "declared types",
+			dialect:  PostgresDialect{},
+			query:    "SELECT :n, :f, :b, :d",
+			declared: []Param{{Name: "n", Type: "int"}, {Name: "f", Type: "float"}, {Name: "b", Type: "bool"}, {Name: "d", Type: "date"}},
+			values:   map[string]string{"n": " 42 ", "f": "1.5", "b": "true", "d": "2024-02-29"},
+			wantSQL:  "SELECT $1, $2, $3, $4",
+			wantArgs: []any{int64(42), 1.5, true, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:     "default",
+			dialect:  PostgresDialect{},
+			query:    "SELECT :limit",
+			declared: []Param{{Name: "limit", Type: "int", Default: &ten}},
+			wantSQL:  "SELECT $1",
+			wantArgs: []any{int64(10)},
+		},
+		{
+			name:    "missing value",
+			dialect: PostgresDialect{},
+			query:   "SELECT :a",
+			wantErr: true,
+		},
+		{
+			name:     "wrong type",
+			dialect:  PostgresDialect{},
+			query:    "SELECT :n",
+			declared: []Param{{Name: "n", Type: "int"}},
+			values:   map[string]string{"n": "ten"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := QueryParams(tt.dialect, Query{SQL: tt.query, Params: tt.declared})
+			sql, args, err := BindParams(tt.dialect, tt.query, params, tt.values)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("BindParams(%q) = %q, want an error", tt.query, sql)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BindParams(%q): %v", tt.query, err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("sql = %q, want %q", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -5,9 +5,14 @@ import (
 )
 
 type Query struct {
-	Name string
-	Id   int
-	SQL  string
+	Name   string
+	Id     int
+	SQL    string
+	Params []Param `yaml:"params,omitempty"`
+
+	// Template runs the SQL through text/template before it is sent; see
+	// RenderQuery
+	Template bool `yaml:"template,omitempty"`
 }
 
 func FindQueryWithSelector(queries map[string]Query, selector string) (Query, bool) {
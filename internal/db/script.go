@@ -0,0 +1,184 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ScriptSyntax holds the lexical quirks of an engine that matter when a
+// script is split into statements.
+type ScriptSyntax struct {
+	BackslashEscapes bool // backslash escapes quotes inside strings: 'It\'s' (MySQL)
+	SlashBlocks      bool // procedural blocks run up to a line holding only "/" (Oracle PL/SQL)
+	NestedComments   bool // block comments nest: /* a /* b */ c */ (PostgreSQL)
+}
+
+var (
+	dollarTag   = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+	plsqlHeader = regexp.MustCompile(`(?is)^CREATE\s+(OR\s+REPLACE\s+)?((NON)?EDITIONABLE\s+)?(PROCEDURE|FUNCTION|PACKAGE|TRIGGER|TYPE)\b`)
+)
+
+// SplitStatements splits a script on semicolons that sit outside of strings,
+// quoted identifiers, comments and dollar-quoted bodies. With SlashBlocks a
+// line holding only "/" ends a statement too, as in SQL*Plus. The terminators
+// are dropped and statements made of comments only are skipped.
+func SplitStatements(script string, syntax ScriptSyntax) []string {
+	var statements []string
+	var current strings.Builder
+
+	flush := func() {
+		stmt := strings.TrimSpace(current.String())
+		current.Reset()
+		if firstKeyword(stmt) != "" {
+			statements = append(statements, stmt)
+		}
+	}
+
+	i := 0
+	lineStart := true
+	for i < len(script) {
+		if lineStart && syntax.SlashBlocks {
+			if rest, ok := slashLine(script[i:]); ok {
+				flush()
+				i = len(script) - len(rest)
+				continue
+			}
+		}
+
+		n := literalLength(script, i, syntax)
+		if n == 0 {
+			n = 1
+			if script[i] == ';' && !(syntax.SlashBlocks && isPLSQLBlock(current.String())) {
+				flush()
+				i++
+				lineStart = false
+				continue
+			}
+		}
+
+		current.WriteString(script[i : i+n])
+		lineStart = strings.HasSuffix(script[i:i+n], "\n")
+		i += n
+	}
+	flush()
+
+	return statements
+}
+
+// literalLength returns the length of the string, quoted identifier, comment
+// or dollar-quoted body starting at s[i], or 0 when plain SQL starts there.
+func literalLength(s string, i int, syntax ScriptSyntax) int {
+	rest := s[i:]
+	switch c := s[i]; {
+	case c == '\'':
+		return quotedLength(rest, '\'', syntax.BackslashEscapes || stringPrefixE(s, i))
+	case c == '"' || c == '`':
+		return quotedLength(rest, c, false)
+	case strings.HasPrefix(rest, "--"):
+		if n := strings.IndexByte(rest, '\n'); n >= 0 {
+			return n
+		}
+		return len(rest)
+	case strings.HasPrefix(rest, "/*"):
+		return commentLength(rest, syntax.NestedComments)
+	case c == '$' && (i == 0 || !isIdentChar(s[i-1])):
+		if tag := dollarTag.FindString(rest); tag != "" {
+			if end := strings.Index(rest[len(tag):], tag); end >= 0 {
+				return len(tag) + end + len(tag)
+			}
+			return len(rest)
+		}
+	}
+	return 0
+}
+
+// commentLength returns the length of the block comment at the start of s,
+// running to the end of s when it isn't closed.
+func commentLength(s string, nested bool) int {
+	depth := 0
+	for i := 0; i+1 < len(s); i++ {
+		switch s[i : i+2] {
+		case "/*":
+			if depth == 0 || nested {
+				depth++
+			}
+			i++
+		case "*/":
+			if depth--; depth == 0 {
+				return i + 2
+			}
+			i++
+		}
+	}
+	return len(s)
+}
+
+// slashLine matches a line made of a "/" and blanks only, returning what
+// follows it.
+func slashLine(s string) (string, bool) {
+	line, rest, _ := strings.Cut(s, "\n")
+	if strings.TrimSpace(line) != "/" {
+		return "", false
+	}
+	return rest, true
+}
+
+// quotedLength returns the length of the quoted text at the start of s,
+// quotes included. A doubled quote stands for itself.
+func quotedLength(s string, quote byte, backslashEscapes bool) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case backslashEscapes && s[i] == '\\':
+			i++
+		case s[i] == quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// stringPrefixE reports whether the string starting at i is a postgres
+// escape string (E'...'), where backslashes escape quotes.
+func stringPrefixE(s string, i int) bool {
+	if i == 0 || (s[i-1] != 'E' && s[i-1] != 'e') {
+		return false
+	}
+	return i == 1 || !isIdentChar(s[i-2])
+}
+
+// isPLSQLBlock reports whether a statement is a PL/SQL block, whose inner
+// semicolons don't end it.
+func isPLSQLBlock(stmt string) bool {
+	switch firstKeyword(stmt) {
+	case "DECLARE", "BEGIN":
+		return true
+	case "CREATE":
+		return plsqlHeader.MatchString(TrimLeadingComments(stmt))
+	}
+	return false
+}
+
+// TrimLeadingComments drops the comments and blanks in front of a statement.
+func TrimLeadingComments(s string) string {
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		switch {
+		case strings.HasPrefix(s, "--"):
+			_, rest, _ := strings.Cut(s, "\n")
+			s = rest
+		case strings.HasPrefix(s, "/*"):
+			_, rest, _ := strings.Cut(s, "*/")
+			s = rest
+		default:
+			return s
+		}
+	}
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c == '#' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
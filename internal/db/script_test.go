@@ -0,0 +1,76 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	mysql := ScriptSyntax{BackslashEscapes: true}
+	oracle := ScriptSyntax{SlashBlocks: true}
+	postgres := ScriptSyntax{NestedComments: true}
+	tests := []struct {
+		name   string
+		syntax ScriptSyntax
+		script string
+		want   []string
+	}{
+		{"single", ScriptSyntax{}, "SELECT 1", []string{"SELECT 1"}},
+		{"semicolons", ScriptSyntax{}, "SELECT 1; SELECT 2;\n", []string{"SELECT 1", "SELECT 2"}},
+		{"empty statements", ScriptSyntax{}, ";; SELECT 1;;", []string{"SELECT 1"}},
+		{"comment only", ScriptSyntax{}, "SELECT 1;\n-- done;\n/* really */", []string{"SELECT 1"}},
+		{"string", ScriptSyntax{}, "SELECT 'a;b'; SELECT 2", []string{"SELECT 'a;b'", "SELECT 2"}},
+		{"doubled quote", ScriptSyntax{}, "SELECT 'it''s;'; SELECT 2", []string{"SELECT 'it''s;'", "SELECT 2"}},
+		{"quoted identifier", ScriptSyntax{}, `SELECT "a;b" FROM t; SELECT 2`, []string{`SELECT "a;b" FROM t`, "SELECT 2"}},
+		{"line comment", ScriptSyntax{}, "SELECT 1 -- no; split\n; SELECT 2", []string{"SELECT 1 -- no; split", "SELECT 2"}},
+		{"block comment", ScriptSyntax{}, "SELECT /* ; */ 1; SELECT 2", []string{"SELECT /* ; */ 1", "SELECT 2"}},
+		{"block comment ends at the first close", ScriptSyntax{}, "SELECT /* a /* b */ 1; SELECT 2", []string{"SELECT /* a /* b */ 1", "SELECT 2"}},
+		{"nested block comments", postgres, "SELECT /* a /* b; */ c; */ 1; SELECT 2", []string{"SELECT /* a /* b; */ c; */ 1", "SELECT 2"}},
+		{"unclosed comment", postgres, "SELECT 1; /* a /* b */ ; SELECT 2", []string{"SELECT 1"}},
+		{
+			"dollar quoted body",
+			ScriptSyntax{},
+			"CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql; SELECT f()",
+			[]string{"CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql", "SELECT f()"},
+		},
+		{
+			"tagged dollar quote",
+			ScriptSyntax{},
+			"SELECT $body$ a; $$ b; $body$; SELECT 2",
+			[]string{"SELECT $body$ a; $$ b; $body$", "SELECT 2"},
+		},
+		{"dollar in identifier", ScriptSyntax{}, "SELECT a$b$ FROM t; SELECT 2", []string{"SELECT a$b$ FROM t", "SELECT 2"}},
+		{"escape string", ScriptSyntax{}, `SELECT E'a\';b'; SELECT 2`, []string{`SELECT E'a\';b'`, "SELECT 2"}},
+		{"backslash without escapes", ScriptSyntax{}, `SELECT 'a\'; SELECT 2`, []string{`SELECT 'a\'`, "SELECT 2"}},
+		{"mysql backslash", mysql, `SELECT 'a\';b'; SELECT 2`, []string{`SELECT 'a\';b'`, "SELECT 2"}},
+		{"slash is sql elsewhere", ScriptSyntax{}, "SELECT 4\n/\n2", []string{"SELECT 4\n/\n2"}},
+		{"slash line", oracle, "SELECT 1 FROM dual\n/\nSELECT 2 FROM dual", []string{"SELECT 1 FROM dual", "SELECT 2 FROM dual"}},
+		{
+			"plsql block",
+			oracle,
+			"BEGIN\n  x := 1;\n  y := 2;\nEND;\n/\nSELECT 1 FROM dual;",
+			[]string{"BEGIN\n  x := 1;\n  y := 2;\nEND;", "SELECT 1 FROM dual"},
+		},
+		{
+			"declare block",
+			oracle,
+			"DECLARE n NUMBER; BEGIN n := 1; END;\n  /  \nSELECT 2 FROM dual",
+			[]string{"DECLARE n NUMBER; BEGIN n := 1; END;", "SELECT 2 FROM dual"},
+		},
+		{
+			"create procedure",
+			oracle,
+			"-- setup\nCREATE OR REPLACE PROCEDURE p AS BEGIN NULL; END;\n/\n",
+			[]string{"-- setup\nCREATE OR REPLACE PROCEDURE p AS BEGIN NULL; END;"},
+		},
+		{"create table is not a block", oracle, "CREATE TABLE t (a int); SELECT 1 FROM dual", []string{"CREATE TABLE t (a int)", "SELECT 1 FROM dual"}},
+		{"slash inside string", oracle, "SELECT '\n/\n' FROM dual", []string{"SELECT '\n/\n' FROM dual"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitStatements(tt.script, tt.syntax); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitStatements(%q) = %q, want %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SQLConnection is the database/sql backed connection used for every engine.
+// Engine specific behavior is delegated to its Dialect.
+type SQLConnection struct {
+	*BaseConnection
+	dialect Dialect
+	db      *sql.DB
+}
+
+func NewSQLConnection(name string, dialect Dialect, connStr string) (*SQLConnection, error) {
+	normalized, err := dialect.NormalizeConnString(connStr)
+	if err != nil {
+		return nil, err
+	}
+	bc := &BaseConnection{
+		Name:       name,
+		DbType:     dialect.Name(),
+		ConnString: normalized,
+	}
+	return &SQLConnection{BaseConnection: bc, dialect: dialect}, nil
+}
+
+func (c *SQLConnection) Open() error {
+	db, err := sql.Open(c.dialect.DriverName(), c.ConnString)
+	if err != nil {
+		return err
+	}
+	c.dialect.ConfigureDB(db, c.ConnString)
+
+	// database/sql connects lazily; with a connect timeout, connect now so an
+	// unreachable server fails fast instead of on the first query
+	if timeout := c.Limits.ConnectTimeout; timeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := db.PingContext(ctx); err != nil {
+			db.Close()
+			if ctx.Err() != nil {
+				return fmt.Errorf("no connection within connect_timeout of %s", timeout)
+			}
+			return err
+		}
+	}
+
+	c.db = db
+	return nil
+}
+
+func (c *SQLConnection) Ping() error {
+	if c.db == nil {
+		return fmt.Errorf("database is not open")
+	}
+	return c.db.Ping()
+}
+
+func (c *SQLConnection) Close() error {
+	if c.db != nil {
+		return c.db.Close()
+	}
+	return nil
+}
+
+func (c *SQLConnection) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if c.db == nil {
+		return nil, fmt.Errorf("database is not open")
+	}
+	return c.db.QueryContext(ctx, query, args...)
+}
+
+func (c *SQLConnection) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if c.db == nil {
+		return nil, fmt.Errorf("database is not open")
+	}
+	return c.db.ExecContext(ctx, query, args...)
+}
+
+func (c *SQLConnection) GetDB() *sql.DB {
+	return c.db
+}
+
+func (c *SQLConnection) GetDialect() Dialect {
+	return c.dialect
+}
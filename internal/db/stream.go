@@ -0,0 +1,178 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// PageSize is how many rows a view reads from a stream at a time.
+const PageSize = 500
+
+// RowStream reads the rows of a query a page at a time, so a view can show
+// the first rows while the rest are still on the server. The statement stays
+// open until every row is read or the stream is closed.
+type RowStream struct {
+	mu        sync.Mutex
+	rows      *sql.Rows
+	release   func()
+	columns   int
+	limit     int
+	read      int
+	done      bool
+	truncated bool
+	closed    atomic.Bool
+}
+
+// StreamTableData reads the columns and the first page of rows and leaves the
+// rest in TableData.Stream. At most limit rows are read (no limit when 0).
+// release, when not nil, is called once the stream is exhausted or closed, to
+// free the statement's context.
+func StreamTableData(rows *sql.Rows, sqlQuery string, conn DatabaseConnection, limit int, release func()) (*TableData, error) {
+	tableData, err := newStreamedTableData(rows, sqlQuery, conn, limit, release)
+	if err != nil {
+		return nil, err
+	}
+	if err := tableData.Fetch(PageSize); err != nil {
+		tableData.Close()
+		return nil, err
+	}
+	return tableData, nil
+}
+
+func newStreamedTableData(rows *sql.Rows, sqlQuery string, conn DatabaseConnection, limit int, release func()) (*TableData, error) {
+	fail := func(format string, err error) (*TableData, error) {
+		rows.Close()
+		if release != nil {
+			release()
+		}
+		return nil, fmt.Errorf(format, err)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return fail("getting columns: %w", err)
+	}
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return fail("getting column types: %w", err)
+	}
+	types := make([]string, len(columnTypes))
+	for i, ct := range columnTypes {
+		types[i] = ct.DatabaseTypeName()
+	}
+
+	return &TableData{
+		Columns:     columns,
+		ColumnTypes: types,
+		TableName:   extractTableName(sqlQuery),
+		SQL:         sqlQuery,
+		Connection:  conn,
+		Stream: &RowStream{
+			rows:    rows,
+			release: release,
+			columns: len(columns),
+			limit:   limit,
+		},
+	}, nil
+}
+
+// Fetch reads up to n more rows, or all remaining rows when n <= 0. The
+// stream closes itself once the rows run out or the limit is reached.
+func (s *RowStream) Fetch(n int) ([]Row, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done {
+		return nil, nil
+	}
+
+	values := make([]any, s.columns)
+	valuePtrs := make([]any, s.columns)
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+
+	var page []Row
+	for n <= 0 || len(page) < n {
+		if !s.rows.Next() {
+			err := s.rows.Err()
+			s.finish()
+			switch {
+			case s.closed.Load():
+				// Closed while this page was read: the rest is left unread
+				s.truncated = true
+			case err != nil:
+				s.truncated = true
+				return page, fmt.Errorf("iterating rows: %w", err)
+			}
+			return page, nil
+		}
+		// A row past the limit. CapRows has the server stop here for a
+		// plain SELECT; anything else is cut short while reading
+		if s.limit > 0 && s.read == s.limit {
+			s.truncated = true
+			s.finish()
+			return page, nil
+		}
+		if err := s.rows.Scan(valuePtrs...); err != nil {
+			s.truncated = true
+			s.finish()
+			return page, fmt.Errorf("scanning row: %w", err)
+		}
+
+		row := make(Row, s.columns)
+		for colIndex, val := range values {
+			row[colIndex] = Cell{
+				Value:       FormatValue(val),
+				RawValue:    val,
+				RowIndex:    s.read,
+				ColumnIndex: colIndex,
+			}
+		}
+		page = append(page, row)
+		s.read++
+	}
+	return page, nil
+}
+
+// Close stops the stream before every row is read. It may be called while a
+// Fetch is waiting on the server; that Fetch returns the rows it has.
+func (s *RowStream) Close() {
+	s.closed.Store(true)
+	s.rows.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.done {
+		s.truncated = true
+		s.finish()
+	}
+}
+
+// Done reports whether the stream has no more rows to give.
+func (s *RowStream) Done() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.done
+}
+
+// Truncated reports whether rows were left unread, because of the row limit
+// or because the stream was closed early.
+func (s *RowStream) Truncated() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.truncated
+}
+
+// finish ends the statement before closing the rows: a driver that drains
+// the rest of a result on Close stops at once when its context is done.
+func (s *RowStream) finish() {
+	s.done = true
+	if s.release != nil {
+		s.release()
+	}
+	s.rows.Close()
+}
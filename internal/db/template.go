@@ -0,0 +1,82 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// templateData is what a template query sees as dot.
+type templateData struct {
+	Vars       map[string]string
+	Connection string
+	DBType     string
+}
+
+// RenderQuery expands a template query with Go's text/template. Besides the
+// standard actions a template can use
+//
+//	{{ .Vars.tenant }}        a variable from the connection's vars
+//	{{ .Connection }}         the connection name, {{ .DBType }} its engine
+//	{{ env "USER" }}          an environment variable, an error when unset
+//	{{ env "DAYS" "7" }}      the same with a fallback
+//	{{ include "recent" }}    another saved query, rendered as a template too
+//
+// Rendered text becomes part of the SQL as is; values typed at run time
+// belong in :name parameters, which are still bound afterwards. Queries
+// without Template set are returned unchanged.
+func RenderQuery(conn DatabaseConnection, q Query) (string, error) {
+	if !q.Template {
+		return q.SQL, nil
+	}
+	return renderQuery(conn, q, nil)
+}
+
+func renderQuery(conn DatabaseConnection, q Query, including []string) (string, error) {
+	including = append(including, q.Name)
+
+	funcs := template.FuncMap{
+		"env": func(name string, fallback ...string) (string, error) {
+			if v, ok := os.LookupEnv(name); ok {
+				return v, nil
+			}
+			if len(fallback) > 0 {
+				return fallback[0], nil
+			}
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		},
+		"include": func(name string) (string, error) {
+			if slices.Contains(including, name) {
+				return "", fmt.Errorf("include cycle: %s → %s", strings.Join(including, " → "), name)
+			}
+			partial, ok := conn.GetQueries()[name]
+			if !ok {
+				return "", fmt.Errorf("no saved query named %q to include", name)
+			}
+			sql, err := renderQuery(conn, partial, including)
+			return strings.TrimSpace(sql), err
+		},
+	}
+
+	tmpl, err := template.New(q.Name).Funcs(funcs).Option("missingkey=error").Parse(q.SQL)
+	if err != nil {
+		return "", err
+	}
+
+	vars := conn.GetVars()
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	var b strings.Builder
+	err = tmpl.Execute(&b, templateData{
+		Vars:       vars,
+		Connection: conn.GetName(),
+		DBType:     conn.GetDbType(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,198 @@
+// Package diff compares two result sets row by row.
+package diff
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eduardofuncao/pam/internal/db"
+)
+
+// Status tells how a row compares between the two results.
+type Status int
+
+const (
+	Same Status = iota
+	Added
+	Removed
+	Changed
+)
+
+// Symbol marks a status in text output: + added, - removed, ~ changed.
+func (s Status) Symbol() string {
+	return [...]string{" ", "+", "-", "~"}[s]
+}
+
+// Row is a row of either result. Old holds the before values of changed
+// rows, with Changed flagging the columns that differ.
+type Row struct {
+	Status  Status
+	Values  db.Row // from after, or from before when removed
+	Old     db.Row
+	Changed []bool
+}
+
+// Result is the comparison of two results with the same columns.
+type Result struct {
+	Columns []string
+	Rows    []Row
+	Counts  map[Status]int
+}
+
+// Differs reports whether any row was added, removed or changed.
+func (r *Result) Differs() bool {
+	return r.Counts[Added]+r.Counts[Removed]+r.Counts[Changed] > 0
+}
+
+// Summary counts the rows of each status, e.g. "2 added, 1 changed, 40 same".
+func (r *Result) Summary() string {
+	var parts []string
+	for _, s := range []Status{Added, Removed, Changed, Same} {
+		if n := r.Counts[s]; n > 0 || s == Same {
+			parts = append(parts, fmt.Sprintf("%d %s", n, [...]string{"same", "added", "removed", "changed"}[s]))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Compare matches the rows of after to those of before on the key columns,
+// or on whole rows when there are none, in which case rows are only ever
+// added or removed. after's columns may come in another order; they are put
+// in before's. Rows keep the order of after, with removed rows placed before
+// the first row that followed them in before.
+func Compare(before, after *db.TableData, keys []string) (*Result, error) {
+	order, err := columnOrder(before.Columns, after.Columns)
+	if err != nil {
+		return nil, err
+	}
+	keyCols := make([]int, len(keys))
+	for i, key := range keys {
+		j := slices.IndexFunc(before.Columns, func(c string) bool { return strings.EqualFold(c, key) })
+		if j < 0 {
+			return nil, fmt.Errorf("no key column %s (columns are %s)", key, strings.Join(before.Columns, ", "))
+		}
+		keyCols[i] = j
+	}
+	if len(keyCols) == 0 {
+		for i := range before.Columns {
+			keyCols = append(keyCols, i)
+		}
+	}
+
+	afterRows := make([]db.Row, len(after.Rows))
+	for i, row := range after.Rows {
+		afterRows[i] = make(db.Row, len(order))
+		for j, from := range order {
+			afterRows[i][j] = row[from]
+		}
+	}
+
+	// Rows of before by key, duplicates paired in order
+	byKey := map[string][]int{}
+	for i, row := range before.Rows {
+		k := rowKey(row, keyCols)
+		byKey[k] = append(byKey[k], i)
+	}
+
+	// Every row is matched first, so a row of before that a later row of
+	// after takes isn't reported as removed on the way
+	matchOf := make([]int, len(afterRows))
+	matched := make([]bool, len(before.Rows))
+	for i, row := range afterRows {
+		matchOf[i] = -1
+		k := rowKey(row, keyCols)
+		if candidates := byKey[k]; len(candidates) > 0 {
+			matchOf[i] = candidates[0]
+			matched[candidates[0]] = true
+			byKey[k] = candidates[1:]
+		}
+	}
+
+	result := &Result{Columns: before.Columns, Counts: map[Status]int{}}
+	nextBefore := 0
+	flushRemoved := func(upTo int) {
+		for ; nextBefore < upTo; nextBefore++ {
+			if !matched[nextBefore] {
+				result.add(Row{Status: Removed, Values: before.Rows[nextBefore]})
+			}
+		}
+	}
+
+	for n, row := range afterRows {
+		i := matchOf[n]
+		if i < 0 {
+			result.add(Row{Status: Added, Values: row})
+			continue
+		}
+		flushRemoved(i)
+
+		changed := make([]bool, len(row))
+		differs := false
+		for j := range row {
+			if cellKey(row[j]) != cellKey(before.Rows[i][j]) {
+				changed[j], differs = true, true
+			}
+		}
+		if differs {
+			result.add(Row{Status: Changed, Values: row, Old: before.Rows[i], Changed: changed})
+		} else {
+			result.add(Row{Status: Same, Values: row})
+		}
+	}
+	flushRemoved(len(before.Rows))
+	return result, nil
+}
+
+func (r *Result) add(row Row) {
+	r.Rows = append(r.Rows, row)
+	r.Counts[row.Status]++
+}
+
+// columnOrder returns, for each of the before columns, its index in after.
+func columnOrder(before, after []string) ([]int, error) {
+	order := make([]int, len(before))
+	var missing []string
+	for i, col := range before {
+		j := slices.IndexFunc(after, func(c string) bool { return strings.EqualFold(c, col) })
+		if j < 0 {
+			missing = append(missing, col)
+			continue
+		}
+		order[i] = j
+	}
+	if len(missing) > 0 || len(after) != len(before) {
+		return nil, fmt.Errorf("the results have different columns: %s and %s", strings.Join(before, ", "), strings.Join(after, ", "))
+	}
+	return order, nil
+}
+
+func rowKey(row db.Row, cols []int) string {
+	var b strings.Builder
+	for _, col := range cols {
+		b.WriteString(cellKey(row[col]))
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// cellKey compares cells by their text, which is what stays the same across
+// engines and drivers, telling NULL apart from the string "NULL".
+func cellKey(cell db.Cell) string {
+	if cell.RawValue == nil {
+		return "\x01"
+	}
+	return "=" + CellText(cell)
+}
+
+// CellText is the text a cell is compared by: its displayed value, or when
+// that isn't UTF-8 (binary data) its hex digits after \x, so the value
+// survives being saved as JSON.
+func CellText(cell db.Cell) string {
+	if !utf8.ValidString(cell.Value) {
+		return `\x` + hex.EncodeToString([]byte(cell.Value))
+	}
+	return cell.Value
+}
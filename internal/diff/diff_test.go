@@ -0,0 +1,180 @@
+package diff
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/eduardofuncao/pam/internal/db"
+)
+
+func table(columns string, rows ...[]any) *db.TableData {
+	return db.NewTableData(strings.Split(columns, ","), nil, rows, nil)
+}
+
+// lines renders a result one row per line, symbol first, changed cells as
+// old>new.
+func lines(r *Result) []string {
+	var out []string
+	for _, row := range r.Rows {
+		cells := make([]string, len(row.Values))
+		for i, cell := range row.Values {
+			cells[i] = cell.Value
+			if row.Changed != nil && row.Changed[i] {
+				cells[i] = row.Old[i].Value + ">" + cell.Value
+			}
+		}
+		out = append(out, row.Status.Symbol()+strings.Join(cells, ","))
+	}
+	return out
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name    string
+		before  *db.TableData
+		after   *db.TableData
+		keys    []string
+		want    []string
+		summary string
+	}{
+		{
+			name:    "by key",
+			before:  table("id,name", []any{1, "a"}, []any{2, "b"}, []any{3, "c"}),
+			after:   table("id,name", []any{1, "a"}, []any{2, "B"}, []any{4, "d"}),
+			keys:    []string{"id"},
+			want:    []string{" 1,a", "~2,b>B", "+4,d", "-3,c"},
+			summary: "1 added, 1 removed, 1 changed, 1 same",
+		},
+		{
+			name:    "whole row",
+			before:  table("id,name", []any{1, "a"}, []any{2, "b"}),
+			after:   table("id,name", []any{1, "a"}, []any{2, "B"}),
+			want:    []string{" 1,a", "+2,B", "-2,b"},
+			summary: "1 added, 1 removed, 1 same",
+		},
+		{
+			name:    "removed rows keep their place",
+			before:  table("id", []any{1}, []any{2}, []any{3}),
+			after:   table("id", []any{1}, []any{3}),
+			keys:    []string{"id"},
+			want:    []string{" 1", "-2", " 3"},
+			summary: "1 removed, 2 same",
+		},
+		{
+			name:    "NULL is not the string NULL",
+			before:  table("id,v", []any{1, nil}),
+			after:   table("id,v", []any{1, "NULL"}),
+			keys:    []string{"id"},
+			want:    []string{"~1,NULL>NULL"},
+			summary: "1 changed, 0 same",
+		},
+		{
+			name:    "NULL rows match whole",
+			before:  table("id,v", []any{1, nil}),
+			after:   table("id,v", []any{1, nil}),
+			want:    []string{" 1,NULL"},
+			summary: "1 same",
+		},
+		{
+			name:    "NULL keys match each other",
+			before:  table("id,v", []any{nil, "x"}),
+			after:   table("id,v", []any{nil, "y"}),
+			keys:    []string{"id"},
+			want:    []string{"~NULL,x>y"},
+			summary: "1 changed, 0 same",
+		},
+		{
+			name:    "duplicate keys pair in order",
+			before:  table("id,v", []any{1, "a"}, []any{1, "b"}),
+			after:   table("id,v", []any{1, "a"}, []any{1, "c"}, []any{1, "d"}),
+			keys:    []string{"id"},
+			want:    []string{" 1,a", "~1,b>c", "+1,d"},
+			summary: "1 added, 1 changed, 1 same",
+		},
+		{
+			name:    "duplicate rows",
+			before:  table("id", []any{1}, []any{1}),
+			after:   table("id", []any{1}),
+			want:    []string{" 1", "-1"},
+			summary: "1 removed, 1 same",
+		},
+		{
+			name:    "composite key",
+			before:  table("a,b,v", []any{1, 1, "x"}, []any{1, 2, "y"}),
+			after:   table("a,b,v", []any{1, 2, "y"}, []any{1, 1, "z"}),
+			keys:    []string{"a", "b"},
+			want:    []string{" 1,2,y", "~1,1,x>z"},
+			summary: "1 changed, 1 same",
+		},
+		{
+			name:    "columns in another order, keys in any case",
+			before:  table("id,name", []any{1, "a"}),
+			after:   table("NAME,ID", []any{"a", 1}),
+			keys:    []string{"Id"},
+			want:    []string{" 1,a"},
+			summary: "1 same",
+		},
+		{
+			name:    "both empty",
+			before:  table("id"),
+			after:   table("id"),
+			summary: "0 same",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Compare(tt.before, tt.after, tt.keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := lines(result); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %q, want %q", got, tt.want)
+			}
+			if got := result.Summary(); got != tt.summary {
+				t.Errorf("Summary() = %q, want %q", got, tt.summary)
+			}
+			differs := slices.ContainsFunc(tt.want, func(line string) bool { return line[0] != ' ' })
+			if result.Differs() != differs {
+				t.Errorf("Differs() = %v, want %v", result.Differs(), differs)
+			}
+		})
+	}
+}
+
+func TestCompareErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		before *db.TableData
+		after  *db.TableData
+		keys   []string
+	}{
+		{"unknown key", table("id"), table("id"), []string{"nope"}},
+		{"missing column", table("id,name"), table("id,title"), nil},
+		{"extra column", table("id"), table("id,name"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compare(tt.before, tt.after, tt.keys); err == nil {
+				t.Error("Compare() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestCellText(t *testing.T) {
+	tests := []struct {
+		cell db.Cell
+		want string
+	}{
+		{db.Cell{Value: "abc", RawValue: "abc"}, "abc"},
+		{db.Cell{Value: "é", RawValue: []byte("é")}, "é"},
+		{db.Cell{Value: "\x00\xff", RawValue: []byte{0, 0xff}}, `\x00ff`},
+	}
+	for _, tt := range tests {
+		if got := CellText(tt.cell); got != tt.want {
+			t.Errorf("CellText(%q) = %q, want %q", tt.cell.Value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Package format writes query results as text for pipes, files and scripts.
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/eduardofuncao/pam/internal/db"
+)
+
+const (
+	Table     = "table"
+	CSV       = "csv"
+	TSV       = "tsv"
+	JSON      = "json"
+	NDJSON    = "ndjson"
+	Markdown  = "markdown"
+	SQLInsert = "sql-insert"
+	XLSX      = "xlsx"
+
+	// Null is how NULL is written in CSV and TSV, as in COPY and LOAD DATA
+	Null = `\N`
+)
+
+// Names lists the formats in the order they are documented.
+var Names = []string{Table, CSV, TSV, JSON, NDJSON, Markdown, SQLInsert, XLSX}
+
+// extensions maps file extensions to the format written into such files.
+var extensions = map[string]string{
+	".csv":    CSV,
+	".tsv":    TSV,
+	".tab":    TSV,
+	".json":   JSON,
+	".ndjson": NDJSON,
+	".jsonl":  NDJSON,
+	".md":     Markdown,
+	".sql":    SQLInsert,
+	".xlsx":   XLSX,
+	".txt":    Table,
+}
+
+// Options tune how rows are written. Defaults gives the ones of each format.
+type Options struct {
+	Header    bool   // column names first (csv, tsv, xlsx)
+	Delimiter rune   // field separator of csv and tsv
+	Null      string // how NULL is written in csv and tsv
+	Strings   bool   // json and ndjson write every value as a string
+	Table     string // table sql-insert writes into, the one the query read when ""
+}
+
+// Defaults returns the options a format is written with unless told
+// otherwise.
+func Defaults(name string) Options {
+	opts := Options{Header: true, Delimiter: ',', Null: Null}
+	if name == TSV {
+		opts.Delimiter = '\t'
+	}
+	return opts
+}
+
+// FromExtension picks the format of a file from its extension.
+func FromExtension(path string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if name, ok := extensions[ext]; ok {
+		return name, nil
+	}
+	known := make([]string, 0, len(extensions))
+	for ext := range extensions {
+		known = append(known, ext)
+	}
+	slices.Sort(known)
+	return "", fmt.Errorf("can't tell the format of %q from its extension (use %s, or --format)", path, strings.Join(known, ", "))
+}
+
+// Default is the format used without --format: the table view in a
+// terminal, and TSV when stdout goes to a pipe or a file.
+func Default(terminal bool) string {
+	if terminal {
+		return Table
+	}
+	return TSV
+}
+
+// Check rejects unknown format names.
+func Check(name string) error {
+	if !slices.Contains(Names, name) {
+		return fmt.Errorf("unknown format %q (use %s)", name, strings.Join(Names, ", "))
+	}
+	return nil
+}
+
+// rowWriter writes one format. begin gets the columns and the first page of
+// rows, for formats that size columns; row is then called for every row,
+// including those of the first page.
+type rowWriter interface {
+	begin(columns []string, first []db.Row) error
+	row(row db.Row) error
+	end() error
+}
+
+// Write writes every row of tableData to w in the given format and returns
+// how many were written. Rows left in the stream are read a page at a time
+// and dropped once written, so results larger than memory go straight
+// through.
+func Write(w io.Writer, name string, tableData *db.TableData, opts Options) (int, error) {
+	if err := Check(name); err != nil {
+		return 0, err
+	}
+	buf := bufio.NewWriter(w)
+	rw, err := newRowWriter(buf, name, tableData, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	if err := rw.begin(tableData.Columns, tableData.Rows); err != nil {
+		return 0, err
+	}
+	for {
+		for _, row := range tableData.Rows {
+			if err := rw.row(row); err != nil {
+				return written, err
+			}
+			written++
+		}
+		if tableData.Stream == nil {
+			break
+		}
+		tableData.Rows = nil
+		if err := tableData.Fetch(db.PageSize); err != nil {
+			return written, err
+		}
+	}
+	if err := rw.end(); err != nil {
+		return written, err
+	}
+	return written, buf.Flush()
+}
+
+func newRowWriter(w *bufio.Writer, name string, tableData *db.TableData, opts Options) (rowWriter, error) {
+	switch name {
+	case CSV, TSV:
+		return newDelimitedWriter(w, opts), nil
+	case JSON, NDJSON:
+		return &jsonWriter{w: w, types: tableData.ColumnTypes, array: name == JSON, strings: opts.Strings}, nil
+	case Markdown:
+		return &markdownWriter{w: w}, nil
+	case SQLInsert:
+		return newInsertWriter(w, tableData, opts.Table)
+	case XLSX:
+		return newXLSXWriter(w, tableData.ColumnTypes, opts.Header), nil
+	}
+	return &gridWriter{w: w}, nil
+}
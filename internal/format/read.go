@@ -0,0 +1,220 @@
+package format
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Readable lists the formats a Reader reads.
+var Readable = []string{CSV, TSV, JSON, NDJSON}
+
+// Reader reads the records of a csv, tsv, json or ndjson file one at a time.
+type Reader interface {
+	// Columns names the fields of every record: the header, or the keys of
+	// the first JSON object. It is nil for csv without a header.
+	Columns() []string
+	// Next returns the next record, NULL being nil, or io.EOF after the last
+	// one. A *RecordError is about that record alone; reading can go on.
+	Next() ([]any, error)
+	// Position tells where the last record came from, e.g. "line 3".
+	Position() string
+}
+
+// RecordError is a record that can't be read, such as a csv line with too
+// many fields or a JSON object with a key the first one didn't have.
+type RecordError struct {
+	Position string
+	Err      error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Position, e.Err)
+}
+
+// NewReader reads r in the given format. Header, Delimiter and Null of opts
+// apply to csv and tsv. JSON numbers come back as json.Number, booleans as
+// bool, and objects and arrays as their JSON text.
+func NewReader(r io.Reader, name string, opts Options) (Reader, error) {
+	switch name {
+	case CSV, TSV:
+		return newDelimitedReader(r, opts)
+	case JSON, NDJSON:
+		return newJSONReader(r, name == JSON)
+	}
+	return nil, fmt.Errorf("can't read %s files (use %s)", name, strings.Join(Readable, ", "))
+}
+
+type delimitedReader struct {
+	r       *csv.Reader
+	null    string
+	columns []string
+	line    int
+}
+
+func newDelimitedReader(r io.Reader, opts Options) (*delimitedReader, error) {
+	cr := csv.NewReader(bufio.NewReader(r))
+	cr.Comma = opts.Delimiter
+	cr.FieldsPerRecord = -1
+	d := &delimitedReader{r: cr, null: opts.Null}
+	if !opts.Header {
+		return d, nil
+	}
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("no header line")
+	}
+	if err != nil {
+		return nil, err
+	}
+	// A byte order mark is left by some spreadsheets
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	d.columns = header
+	return d, nil
+}
+
+func (d *delimitedReader) Columns() []string {
+	return d.columns
+}
+
+func (d *delimitedReader) Next() ([]any, error) {
+	fields, err := d.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("line %d: %w", parseErr.StartLine, parseErr.Err)
+		}
+		return nil, err
+	}
+	d.line, _ = d.r.FieldPos(0)
+	if d.columns != nil && len(fields) != len(d.columns) {
+		return nil, &RecordError{Position: d.Position(), Err: fmt.Errorf("%d fields, the header has %d", len(fields), len(d.columns))}
+	}
+	record := make([]any, len(fields))
+	for i, field := range fields {
+		if field != d.null {
+			record[i] = field
+		}
+	}
+	return record, nil
+}
+
+func (d *delimitedReader) Position() string {
+	return fmt.Sprintf("line %d", d.line)
+}
+
+// jsonReader reads an array of objects, or objects one after the other for
+// ndjson, without holding more than the current one.
+type jsonReader struct {
+	dec     *json.Decoder
+	array   bool
+	columns []string
+	first   []any
+	records int
+}
+
+func newJSONReader(r io.Reader, array bool) (*jsonReader, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	dec.UseNumber()
+	j := &jsonReader{dec: dec, array: array}
+	if array {
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return nil, fmt.Errorf("a json file holds an array of objects")
+		}
+	}
+
+	// The keys of the first object name the columns
+	keys, values, err := j.object()
+	if err == io.EOF {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	j.columns = keys
+	j.first = make([]any, len(keys))
+	for i, key := range keys {
+		j.first[i] = values[key]
+	}
+	return j, nil
+}
+
+func (j *jsonReader) Columns() []string {
+	return j.columns
+}
+
+func (j *jsonReader) Next() ([]any, error) {
+	if j.first != nil {
+		record := j.first
+		j.first = nil
+		return record, nil
+	}
+	keys, values, err := j.object()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if !slices.Contains(j.columns, key) {
+			return nil, &RecordError{Position: j.Position(), Err: fmt.Errorf("key %q is not in the first object", key)}
+		}
+	}
+	// Keys left out are NULL
+	record := make([]any, len(j.columns))
+	for i, col := range j.columns {
+		record[i] = values[col]
+	}
+	return record, nil
+}
+
+func (j *jsonReader) Position() string {
+	return fmt.Sprintf("record %d", j.records)
+}
+
+// object decodes the next object, returning its keys in order.
+func (j *jsonReader) object() ([]string, map[string]any, error) {
+	if j.array && !j.dec.More() {
+		return nil, nil, io.EOF
+	}
+	j.records++
+	tok, err := j.dec.Token()
+	if err == io.EOF && !j.array {
+		return nil, nil, io.EOF
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", j.Position(), err)
+	}
+	if tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("%s: expected an object, got %v", j.Position(), tok)
+	}
+
+	var keys []string
+	values := map[string]any{}
+	for j.dec.More() {
+		tok, err := j.dec.Token()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", j.Position(), err)
+		}
+		key := tok.(string)
+		var value any
+		if err := j.dec.Decode(&value); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", j.Position(), err)
+		}
+		switch value.(type) {
+		case map[string]any, []any:
+			value = string(marshal(value))
+		}
+		if _, dup := values[key]; !dup {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	if _, err := j.dec.Token(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", j.Position(), err)
+	}
+	return keys, values, nil
+}
@@ -0,0 +1,315 @@
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/eduardofuncao/pam/internal/db"
+	"github.com/mattn/go-runewidth"
+)
+
+// gridCellWidth caps the width of a column in the table format.
+const gridCellWidth = 40
+
+// delimitedWriter writes CSV, or TSV with a tab as separator.
+type delimitedWriter struct {
+	w      *csv.Writer
+	header bool
+	null   string
+}
+
+func newDelimitedWriter(w *bufio.Writer, opts Options) *delimitedWriter {
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.Delimiter
+	return &delimitedWriter{w: cw, header: opts.Header, null: opts.Null}
+}
+
+func (d *delimitedWriter) begin(columns []string, _ []db.Row) error {
+	if !d.header {
+		return nil
+	}
+	return d.w.Write(columns)
+}
+
+func (d *delimitedWriter) row(row db.Row) error {
+	record := make([]string, len(row))
+	for i, cell := range row {
+		if cell.RawValue == nil {
+			record[i] = d.null
+		} else {
+			record[i] = cell.Value
+		}
+	}
+	return d.w.Write(record)
+}
+
+func (d *delimitedWriter) end() error {
+	d.w.Flush()
+	return d.w.Error()
+}
+
+// jsonWriter writes one object per row with keys in column order, as an array
+// or as newline-delimited JSON.
+type jsonWriter struct {
+	w       *bufio.Writer
+	types   []string
+	array   bool
+	strings bool
+	columns []string
+	rows    int
+}
+
+func (j *jsonWriter) begin(columns []string, _ []db.Row) error {
+	j.columns = columns
+	if j.array {
+		_, err := j.w.WriteString("[")
+		return err
+	}
+	return nil
+}
+
+func (j *jsonWriter) row(row db.Row) error {
+	if j.array {
+		sep := "\n  "
+		if j.rows > 0 {
+			sep = ",\n  "
+		}
+		j.w.WriteString(sep)
+	}
+	j.rows++
+
+	j.w.WriteString("{")
+	for i, cell := range row {
+		if i > 0 {
+			j.w.WriteString(", ")
+		}
+		key := marshal(j.columns[i])
+		j.w.Write(key)
+		j.w.WriteString(": ")
+		if j.strings && cell.RawValue != nil {
+			j.w.Write(marshal(cell.Value))
+		} else {
+			j.w.WriteString(jsonValue(cell, columnType(j.types, i)))
+		}
+	}
+	_, err := j.w.WriteString("}")
+	if !j.array {
+		_, err = j.w.WriteString("\n")
+	}
+	return err
+}
+
+func (j *jsonWriter) end() error {
+	if !j.array {
+		return nil
+	}
+	if j.rows > 0 {
+		j.w.WriteString("\n")
+	}
+	_, err := j.w.WriteString("]\n")
+	return err
+}
+
+// jsonValue keeps numbers and booleans typed.
+func jsonValue(cell db.Cell, colType string) string {
+	switch raw := cell.RawValue.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(raw)
+	}
+	if number, ok := numberText(cell, colType); ok {
+		return number
+	}
+	return string(marshal(cell.Value))
+}
+
+// numberText returns a value as a number literal when it is one. Drivers that
+// scan numbers as text (mysql) are recognized by the column type; NaN and
+// infinities aren't numbers in JSON or spreadsheets.
+func numberText(cell db.Cell, colType string) (string, bool) {
+	switch raw := cell.RawValue.(type) {
+	case int64:
+		return strconv.FormatInt(raw, 10), true
+	case float64:
+		if math.IsNaN(raw) || math.IsInf(raw, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(raw, 'g', -1, 64), true
+	case []byte, string:
+		if isNumericType(colType) && isJSONNumber(cell.Value) {
+			return cell.Value, true
+		}
+	}
+	return "", false
+}
+
+// marshal encodes a value without escaping <, > and &, which SQL is full of.
+func marshal(v any) []byte {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+}
+
+func isNumericType(colType string) bool {
+	t := strings.ToUpper(colType)
+	for _, numeric := range []string{"INT", "DECIMAL", "NUMERIC", "NUMBER", "FLOAT", "DOUBLE", "REAL"} {
+		if strings.Contains(t, numeric) {
+			return true
+		}
+	}
+	return false
+}
+
+func isJSONNumber(s string) bool {
+	var n json.Number
+	return s != "" && json.Unmarshal([]byte(s), &n) == nil
+}
+
+func columnType(types []string, i int) string {
+	if i < len(types) {
+		return types[i]
+	}
+	return ""
+}
+
+// markdownWriter writes a GitHub-flavored markdown table.
+type markdownWriter struct {
+	w *bufio.Writer
+}
+
+func (m *markdownWriter) begin(columns []string, _ []db.Row) error {
+	m.line(columns)
+	rules := make([]string, len(columns))
+	for i := range rules {
+		rules[i] = "---"
+	}
+	return m.line(rules)
+}
+
+func (m *markdownWriter) row(row db.Row) error {
+	values := make([]string, len(row))
+	for i, cell := range row {
+		values[i] = cell.Value
+	}
+	return m.line(values)
+}
+
+func (m *markdownWriter) end() error {
+	return nil
+}
+
+func (m *markdownWriter) line(values []string) error {
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>").Replace(v)
+	}
+	_, err := fmt.Fprintf(m.w, "| %s |\n", strings.Join(escaped, " | "))
+	return err
+}
+
+// insertWriter writes one INSERT per row into the table the query read.
+type insertWriter struct {
+	w       *bufio.Writer
+	dialect db.Dialect
+	prefix  string
+	types   []string
+}
+
+func newInsertWriter(w *bufio.Writer, tableData *db.TableData, tableName string) (*insertWriter, error) {
+	if tableName == "" {
+		tableName = tableData.TableName
+	}
+	if tableData.Connection == nil || tableName == "" {
+		return nil, fmt.Errorf("sql-insert needs a query that reads a single table, or a table name to insert into")
+	}
+	d := tableData.Connection.GetDialect()
+	table, err := db.QuoteName(d, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return &insertWriter{w: w, dialect: d, prefix: "INSERT INTO " + table, types: tableData.ColumnTypes}, nil
+}
+
+func (s *insertWriter) begin(columns []string, _ []db.Row) error {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = s.dialect.QuoteIdentifier(col)
+	}
+	s.prefix += " (" + strings.Join(quoted, ", ") + ") VALUES ("
+	return nil
+}
+
+func (s *insertWriter) row(row db.Row) error {
+	values := make([]string, len(row))
+	for i, cell := range row {
+		values[i] = db.Literal(s.dialect, cell.RawValue, s.dialect.ColumnKind(columnType(s.types, i)))
+	}
+	_, err := fmt.Fprintf(s.w, "%s%s);\n", s.prefix, strings.Join(values, ", "))
+	return err
+}
+
+func (s *insertWriter) end() error {
+	return nil
+}
+
+// gridWriter writes a plain text grid sized on the first page of rows,
+// cutting long values.
+type gridWriter struct {
+	w      *bufio.Writer
+	widths []int
+}
+
+func (g *gridWriter) begin(columns []string, first []db.Row) error {
+	g.widths = make([]int, len(columns))
+	for i, col := range columns {
+		g.widths[i] = min(runewidth.StringWidth(col), gridCellWidth)
+	}
+	for _, row := range first {
+		for i, cell := range row {
+			g.widths[i] = max(g.widths[i], min(runewidth.StringWidth(gridValue(cell.Value)), gridCellWidth))
+		}
+	}
+
+	g.line(columns)
+	rules := make([]string, len(g.widths))
+	for i, width := range g.widths {
+		rules[i] = strings.Repeat("─", width)
+	}
+	_, err := fmt.Fprintln(g.w, strings.Join(rules, "─┼─"))
+	return err
+}
+
+func (g *gridWriter) row(row db.Row) error {
+	values := make([]string, len(row))
+	for i, cell := range row {
+		values[i] = cell.Value
+	}
+	return g.line(values)
+}
+
+func (g *gridWriter) end() error {
+	return nil
+}
+
+func (g *gridWriter) line(values []string) error {
+	cells := make([]string, len(values))
+	for i, v := range values {
+		cells[i] = runewidth.FillRight(runewidth.Truncate(gridValue(v), g.widths[i], "…"), g.widths[i])
+	}
+	_, err := fmt.Fprintln(g.w, strings.TrimRight(strings.Join(cells, " │ "), " "))
+	return err
+}
+
+// gridValue keeps a value on one line.
+func gridValue(v string) string {
+	return strings.NewReplacer("\n", "↵", "\r", "", "\t", " ").Replace(v)
+}
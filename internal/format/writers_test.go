@@ -0,0 +1,240 @@
+package format
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eduardofuncao/pam/internal/db"
+)
+
+// dialectConn is a connection that only knows its dialect, all sql-insert
+// asks of it.
+type dialectConn struct {
+	db.DatabaseConnection
+	dialect db.Dialect
+}
+
+func (c dialectConn) GetDialect() db.Dialect { return c.dialect }
+
+func write(t *testing.T, name string, tableData *db.TableData, opts Options) string {
+	t.Helper()
+	var b bytes.Buffer
+	if _, err := Write(&b, name, tableData, opts); err != nil {
+		t.Fatalf("Write(%s): %v", name, err)
+	}
+	return b.String()
+}
+
+func TestWriteDelimited(t *testing.T) {
+	rows := [][]any{
+		{int64(1), "plain", nil},
+		{int64(2), "a,b", ""},
+		{int64(3), `say "hi"`, "line\nbreak"},
+		{int64(4), "tab\there", "semi;colon"},
+	}
+	tableData := func() *db.TableData {
+		return db.NewTableData([]string{"id", "name", "note"}, nil, rows, nil)
+	}
+	noHeader := Defaults(CSV)
+	noHeader.Header = false
+	emptyNull := Defaults(CSV)
+	emptyNull.Null = ""
+	semicolon := Defaults(CSV)
+	semicolon.Delimiter = ';'
+
+	tests := []struct {
+		name   string
+		format string
+		opts   Options
+		want   string
+	}{
+		{"csv", CSV, Defaults(CSV), "id,name,note\n" +
+			`1,plain,\N` + "\n" +
+			`2,"a,b",` + "\n" +
+			`3,"say ""hi""","line` + "\nbreak\"\n" +
+			"4,tab\there,semi;colon\n"},
+		{"no header", CSV, noHeader, `1,plain,\N` + "\n" +
+			`2,"a,b",` + "\n" +
+			`3,"say ""hi""","line` + "\nbreak\"\n" +
+			"4,tab\there,semi;colon\n"},
+		{"null as empty", CSV, emptyNull, "id,name,note\n" +
+			"1,plain,\n" +
+			`2,"a,b",` + "\n" +
+			`3,"say ""hi""","line` + "\nbreak\"\n" +
+			"4,tab\there,semi;colon\n"},
+		{"delimiter", CSV, semicolon, "id;name;note\n" +
+			`1;plain;\N` + "\n" +
+			"2;a,b;\n" +
+			`3;"say ""hi""";"line` + "\nbreak\"\n" +
+			"4;tab\there;\"semi;colon\"\n"},
+		{"tsv", TSV, Defaults(TSV), "id\tname\tnote\n" +
+			"1\tplain\t\\N\n" +
+			"2\ta,b\t\n" +
+			"3\t\"say \"\"hi\"\"\"\t\"line\nbreak\"\n" +
+			"4\t\"tab\there\"\tsemi;colon\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := write(t, tt.format, tableData(), tt.opts); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	stringsOpt := Defaults(JSON)
+	stringsOpt.Strings = true
+
+	tests := []struct {
+		name    string
+		format  string
+		opts    Options
+		columns []string
+		types   []string
+		rows    [][]any
+		want    string
+	}{
+		{
+			name:    "typed values",
+			format:  NDJSON,
+			columns: []string{"n", "f", "b", "s", "null"},
+			rows:    [][]any{{int64(7), 1.5, true, "x", nil}},
+			want:    `{"n": 7, "f": 1.5, "b": true, "s": "x", "null": null}` + "\n",
+		},
+		{
+			name:    "numbers as text by column type",
+			format:  NDJSON,
+			columns: []string{"amount", "code"},
+			types:   []string{"DECIMAL", "VARCHAR"},
+			rows:    [][]any{{"1.50", "007"}},
+			want:    `{"amount": 1.50, "code": "007"}` + "\n",
+		},
+		{
+			name:    "text that isn't a number stays a string",
+			format:  NDJSON,
+			columns: []string{"amount"},
+			types:   []string{"NUMERIC"},
+			rows:    [][]any{{"1.2.3"}},
+			want:    `{"amount": "1.2.3"}` + "\n",
+		},
+		{
+			name:    "no number for NaN",
+			format:  NDJSON,
+			columns: []string{"f"},
+			rows:    [][]any{{math.NaN()}},
+			want:    `{"f": "NaN"}` + "\n",
+		},
+		{
+			name:    "escaping",
+			format:  NDJSON,
+			columns: []string{`a"b`, "sql"},
+			rows:    [][]any{{"line\nbreak", "a < b && c > d"}},
+			want:    `{"a\"b": "line\nbreak", "sql": "a < b && c > d"}` + "\n",
+		},
+		{
+			name:    "strings",
+			format:  JSON,
+			opts:    stringsOpt,
+			columns: []string{"n", "b", "null"},
+			rows:    [][]any{{int64(7), true, nil}},
+			want:    "[\n  {\"n\": \"7\", \"b\": \"true\", \"null\": null}\n]\n",
+		},
+		{
+			name:    "array",
+			format:  JSON,
+			columns: []string{"n"},
+			rows:    [][]any{{int64(1)}, {int64(2)}},
+			want:    "[\n  {\"n\": 1},\n  {\"n\": 2}\n]\n",
+		},
+		{
+			name:    "empty array",
+			format:  JSON,
+			columns: []string{"n"},
+			want:    "[]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tableData := db.NewTableData(tt.columns, tt.types, tt.rows, nil)
+			if got := write(t, tt.format, tableData, tt.opts); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteSQLInsert(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := [][]any{{int64(1), "O'Brien", nil}, {int64(2), `back\slash`, true}, {int64(3), at, 2.5}}
+
+	tests := []struct {
+		name    string
+		dialect db.Dialect
+		table   string
+		want    string
+	}{
+		{"postgres", db.PostgresDialect{}, "people", `INSERT INTO "people" ("id", "name", "x") VALUES (1, 'O''Brien', NULL);
+INSERT INTO "people" ("id", "name", "x") VALUES (2, 'back\slash', TRUE);
+INSERT INTO "people" ("id", "name", "x") VALUES (3, '2024-01-02 03:04:05', 2.5);
+`},
+		{"mysql escapes backslashes", db.MySQLDialect{}, "people", "INSERT INTO `people` (`id`, `name`, `x`) VALUES (1, 'O''Brien', NULL);\n" +
+			"INSERT INTO `people` (`id`, `name`, `x`) VALUES (2, 'back\\\\slash', TRUE);\n" +
+			"INSERT INTO `people` (`id`, `name`, `x`) VALUES (3, '2024-01-02 03:04:05', 2.5);\n"},
+		{"schema qualified", db.PostgresDialect{}, "hr.people", `INSERT INTO "hr"."people" ("id", "name", "x") VALUES (1, 'O''Brien', NULL);
+INSERT INTO "hr"."people" ("id", "name", "x") VALUES (2, 'back\slash', TRUE);
+INSERT INTO "hr"."people" ("id", "name", "x") VALUES (3, '2024-01-02 03:04:05', 2.5);
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tableData := db.NewTableData([]string{"id", "name", "x"}, nil, rows, dialectConn{dialect: tt.dialect})
+			opts := Defaults(SQLInsert)
+			opts.Table = tt.table
+			if got := write(t, SQLInsert, tableData, opts); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("typed literals", func(t *testing.T) {
+		columns := []string{"flag", "data", "text", "at", "f"}
+		types := []string{"BOOLEAN", "BLOB", "VARCHAR", "TIMESTAMP", "DOUBLE"}
+		rows := [][]any{{true, []byte{0x00, 0xff}, []byte("a'b"), at, math.Inf(-1)}, {false, []byte("ok"), nil, at, math.NaN()}}
+		tests := []struct {
+			name    string
+			dialect db.Dialect
+			want    string
+		}{
+			{"sqlite", db.SQLiteDialect{}, `INSERT INTO "t" ("flag", "data", "text", "at", "f") VALUES (TRUE, X'00ff', 'a''b', '2024-01-02 03:04:05', '-Infinity');
+INSERT INTO "t" ("flag", "data", "text", "at", "f") VALUES (FALSE, X'6f6b', NULL, '2024-01-02 03:04:05', 'NaN');
+`},
+			{"postgres", db.PostgresDialect{}, `INSERT INTO "t" ("flag", "data", "text", "at", "f") VALUES (TRUE, decode('00ff', 'hex'), 'a''b', '2024-01-02 03:04:05', '-Infinity');
+INSERT INTO "t" ("flag", "data", "text", "at", "f") VALUES (FALSE, decode('6f6b', 'hex'), NULL, '2024-01-02 03:04:05', 'NaN');
+`},
+			{"oracle", db.OracleDialect{}, `INSERT INTO "T" ("flag", "data", "text", "at", "f") VALUES (1, HEXTORAW('00ff'), 'a''b', TIMESTAMP '2024-01-02 03:04:05', '-Infinity');
+INSERT INTO "T" ("flag", "data", "text", "at", "f") VALUES (0, HEXTORAW('6f6b'), NULL, TIMESTAMP '2024-01-02 03:04:05', 'NaN');
+`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tableData := db.NewTableData(columns, types, rows, dialectConn{dialect: tt.dialect})
+				opts := Defaults(SQLInsert)
+				opts.Table = "t"
+				if got := write(t, SQLInsert, tableData, opts); got != tt.want {
+					t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("needs a table", func(t *testing.T) {
+		tableData := db.NewTableData([]string{"id"}, nil, nil, dialectConn{dialect: db.PostgresDialect{}})
+		if _, err := Write(&bytes.Buffer{}, SQLInsert, tableData, Defaults(SQLInsert)); err == nil || !strings.Contains(err.Error(), "table") {
+			t.Errorf("Write() error = %v, want one asking for a table", err)
+		}
+	})
+}
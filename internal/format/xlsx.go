@@ -0,0 +1,144 @@
+package format
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/eduardofuncao/pam/internal/db"
+)
+
+// xlsxMaxRows is the number of rows a worksheet holds.
+const xlsxMaxRows = 1048576
+
+// The parts of a workbook with a single sheet, besides the sheet itself.
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="1"><fill><patternFill patternType="none"/></fill></fills>
+<borders count="1"><border/></borders>
+<cellStyleXfs count="1"><xf/></cellStyleXfs>
+<cellXfs count="2"><xf/><xf fontId="1" applyFont="1"/></cellXfs>
+</styleSheet>`},
+}
+
+// xlsxWriter writes a workbook with one sheet. The sheet is streamed into the
+// zip with its strings inline, so nothing but the current row is held.
+// Numbers and booleans keep their type; NULL leaves the cell empty.
+type xlsxWriter struct {
+	zip    *zip.Writer
+	sheet  *bufio.Writer
+	types  []string
+	header bool
+	rows   int
+}
+
+func newXLSXWriter(w io.Writer, types []string, header bool) *xlsxWriter {
+	return &xlsxWriter{zip: zip.NewWriter(w), types: types, header: header}
+}
+
+func (x *xlsxWriter) begin(columns []string, _ []db.Row) error {
+	for _, part := range xlsxParts {
+		f, err := x.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+
+	f, err := x.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	x.sheet = bufio.NewWriter(f)
+	x.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	x.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	if !x.header {
+		return nil
+	}
+	x.rows++
+	x.sheet.WriteString(`<row>`)
+	for _, col := range columns {
+		x.sheet.WriteString(`<c t="inlineStr" s="1">`)
+		x.inlineString(col)
+		x.sheet.WriteString(`</c>`)
+	}
+	_, err = x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxWriter) row(row db.Row) error {
+	if x.rows >= xlsxMaxRows {
+		return fmt.Errorf("xlsx sheets hold at most %d rows, use csv for more", xlsxMaxRows)
+	}
+	x.rows++
+
+	x.sheet.WriteString(`<row>`)
+	for i, cell := range row {
+		switch raw := cell.RawValue.(type) {
+		case nil:
+			x.sheet.WriteString(`<c/>`)
+			continue
+		case bool:
+			v := "0"
+			if raw {
+				v = "1"
+			}
+			x.sheet.WriteString(`<c t="b"><v>` + v + `</v></c>`)
+			continue
+		}
+		if number, ok := numberText(cell, columnType(x.types, i)); ok {
+			x.sheet.WriteString(`<c><v>` + number + `</v></c>`)
+			continue
+		}
+		x.sheet.WriteString(`<c t="inlineStr">`)
+		x.inlineString(cell.Value)
+		x.sheet.WriteString(`</c>`)
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxWriter) end() error {
+	x.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+func (x *xlsxWriter) inlineString(s string) {
+	x.sheet.WriteString(`<is><t xml:space="preserve">`)
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(s))
+	x.sheet.WriteString(escaped.String())
+	x.sheet.WriteString(`</t></is>`)
+}
@@ -0,0 +1,214 @@
+// Package history keeps a log of the statements pam ran, one JSON object per
+// line, newest last.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultSize is the number of entries kept when history.size is not set.
+const DefaultSize = 1000
+
+// Entry is one statement run.
+type Entry struct {
+	Time       time.Time         `json:"time"`
+	Connection string            `json:"connection"`
+	Query      string            `json:"query,omitempty"` // saved query name
+	SQL        string            `json:"sql"`             // before binding, with :name parameters
+	Params     map[string]string `json:"params,omitempty"`
+	Duration   time.Duration     `json:"duration"`
+	Rows       int64             `json:"rows"`                // rows read or affected, -1 when unknown
+	MoreRows   bool              `json:"more_rows,omitempty"` // the result had rows that were never read
+	Error      string            `json:"error,omitempty"`
+	Snapshot   string            `json:"snapshot,omitempty"` // file holding the result, for diffs
+}
+
+// Snapshot is a result kept to diff later runs against.
+type Snapshot struct {
+	Columns []string    `json:"columns"`
+	Types   []string    `json:"types,omitempty"`
+	Rows    [][]*string `json:"rows"` // NULL is nil
+}
+
+// Failed reports whether the statement ended in an error or was cancelled.
+func (e Entry) Failed() bool {
+	return e.Error != ""
+}
+
+// Size resolves the configured history size: 0 means DefaultSize and a
+// negative size turns history off.
+func Size(configured int) int {
+	if configured == 0 {
+		return DefaultSize
+	}
+	return max(configured, 0)
+}
+
+// Append adds an entry to the history file and drops the oldest ones beyond
+// size. A size of 0 or less records nothing. Concurrent pam processes take
+// turns through a lock file, so no entry is lost.
+func Append(path string, entry Entry, size int) error {
+	if size <= 0 {
+		return nil
+	}
+	var encoded bytes.Buffer
+	enc := json.NewEncoder(&encoded)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(entry); err != nil {
+		return err
+	}
+	line := bytes.TrimSpace(encoded.Bytes())
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	lines, err := readLines(path)
+	if err != nil {
+		return err
+	}
+	if len(lines) < size {
+		return appendLine(path, line)
+	}
+
+	lines = append(lines, line)
+	dropSnapshots(path, lines[:len(lines)-size])
+	lines = lines[len(lines)-size:]
+
+	// Write aside and rename so an interrupted write can't cut the file short
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(bytes.Join(lines, []byte("\n")), '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// appendLine adds a line at the end of the file, starting a new line first
+// should the last write have been cut short.
+func appendLine(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+			return err
+		}
+		if last[0] != '\n' {
+			line = append([]byte{'\n'}, line...)
+		}
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Load reads every entry, oldest first. Lines that don't parse are skipped.
+func Load(path string) ([]Entry, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(lines))
+	for _, line := range lines {
+		var entry Entry
+		if json.Unmarshal(line, &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func readLines(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			lines = append(lines, bytes.Clone(line))
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// SaveSnapshot writes a result next to the history file and returns the name
+// to keep in Entry.Snapshot. It is removed with the entry.
+func SaveSnapshot(path string, snapshot Snapshot) (string, error) {
+	dir := snapshotDir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, time.Now().UTC().Format("20060102-150405-*.json"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(snapshot); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return filepath.Base(f.Name()), nil
+}
+
+// LoadSnapshot reads a result saved by SaveSnapshot.
+func LoadSnapshot(path, name string) (*Snapshot, error) {
+	content, err := os.ReadFile(filepath.Join(snapshotDir(path), filepath.Base(name)))
+	if err != nil {
+		return nil, err
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func snapshotDir(path string) string {
+	return filepath.Join(filepath.Dir(path), "snapshots")
+}
+
+// dropSnapshots removes the snapshots of entries trimmed off the history.
+func dropSnapshots(path string, lines [][]byte) {
+	for _, line := range lines {
+		var entry Entry
+		if json.Unmarshal(line, &entry) == nil && entry.Snapshot != "" {
+			os.Remove(filepath.Join(snapshotDir(path), filepath.Base(entry.Snapshot)))
+		}
+	}
+}
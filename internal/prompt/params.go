@@ -0,0 +1,182 @@
+// Package prompt asks for values in a small form before a command runs.
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/eduardofuncao/pam/internal/db"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	colorTitle = "205"
+	colorKey   = "205"
+	colorName  = "255"
+	colorHint  = "240"
+	colorError = "196"
+	inputWidth = 50
+)
+
+// ErrCancelled is returned when the form is left with Esc or Ctrl+C.
+var ErrCancelled = errors.New("cancelled")
+
+type paramField struct {
+	param db.Param
+	input textinput.Model
+}
+
+type paramsForm struct {
+	title     string
+	fields    []paramField
+	focus     int
+	err       string
+	submitted bool
+}
+
+// Params asks for the parameters of a query, pre-filled with the values
+// already given and the declared defaults. It returns every value, or
+// ErrCancelled.
+func Params(title string, params []db.Param, values map[string]string) (map[string]string, error) {
+	form := &paramsForm{title: title}
+	firstEmpty := -1
+	for i, p := range params {
+		ti := textinput.New()
+		ti.Prompt = ""
+		ti.CharLimit = 0
+		ti.Width = inputWidth
+		if v, ok := values[p.Name]; ok {
+			ti.SetValue(v)
+		} else if p.Default != nil {
+			ti.SetValue(*p.Default)
+		} else if firstEmpty < 0 {
+			firstEmpty = i
+		}
+		form.fields = append(form.fields, paramField{param: p, input: ti})
+	}
+	form.focusField(max(firstEmpty, 0))
+
+	result, err := tea.NewProgram(form).Run()
+	if err != nil {
+		return nil, err
+	}
+	form = result.(*paramsForm)
+	if !form.submitted {
+		return nil, ErrCancelled
+	}
+
+	filled := make(map[string]string, len(form.fields))
+	for _, f := range form.fields {
+		filled[f.param.Name] = f.input.Value()
+	}
+	return filled, nil
+}
+
+func (f *paramsForm) focusField(i int) {
+	if len(f.fields) == 0 {
+		return
+	}
+	f.fields[f.focus].input.Blur()
+	f.focus = (i + len(f.fields)) % len(f.fields)
+	f.fields[f.focus].input.Focus()
+}
+
+func (f *paramsForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (f *paramsForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return f, nil
+	}
+
+	switch key.String() {
+	case "esc", "ctrl+c":
+		return f, tea.Quit
+	case "tab", "down":
+		f.focusField(f.focus + 1)
+		return f, nil
+	case "shift+tab", "up":
+		f.focusField(f.focus - 1)
+		return f, nil
+	case "enter":
+		// Enter moves on until the last field, which submits
+		if f.focus < len(f.fields)-1 {
+			f.focusField(f.focus + 1)
+			return f, nil
+		}
+		return f.submit()
+	}
+
+	var cmd tea.Cmd
+	f.fields[f.focus].input, cmd = f.fields[f.focus].input.Update(msg)
+	f.err = ""
+	return f, cmd
+}
+
+// submit checks every value against its type before leaving the form.
+func (f *paramsForm) submit() (tea.Model, tea.Cmd) {
+	for i, field := range f.fields {
+		if _, err := field.param.Convert(field.input.Value()); err != nil {
+			f.err = err.Error()
+			f.focusField(i)
+			return f, nil
+		}
+	}
+	f.submitted = true
+	return f, tea.Quit
+}
+
+func (f *paramsForm) View() string {
+	if f.submitted {
+		return ""
+	}
+
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorTitle)).Bold(true)
+	nameStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorName)).Bold(true)
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorHint))
+	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorKey)).Bold(true)
+
+	nameWidth, typeWidth := 0, 0
+	for _, field := range f.fields {
+		nameWidth = max(nameWidth, runewidth.StringWidth(field.param.Name)+1)
+		typeWidth = max(typeWidth, runewidth.StringWidth(paramType(field.param)))
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(f.title))
+	b.WriteString("\n\n")
+	for i, field := range f.fields {
+		marker := "  "
+		if i == f.focus {
+			marker = keyStyle.Render("› ")
+		}
+		b.WriteString(fmt.Sprintf("%s%s  %s  %s\n",
+			marker,
+			nameStyle.Render(runewidth.FillRight(":"+field.param.Name, nameWidth)),
+			hintStyle.Render(runewidth.FillRight(paramType(field.param), typeWidth)),
+			field.input.View()))
+	}
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("%s %s  %s %s  %s %s\n",
+		keyStyle.Render("enter"), hintStyle.Render("next / run"),
+		keyStyle.Render("tab"), hintStyle.Render("next"),
+		keyStyle.Render("esc"), hintStyle.Render("cancel")))
+	if f.err != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(colorError)).Bold(true).Render(f.err))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func paramType(p db.Param) string {
+	if p.Type == "" {
+		return "string"
+	}
+	return p.Type
+}
@@ -0,0 +1,268 @@
+package table
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eduardofuncao/pam/internal/db"
+)
+
+const (
+	bulkRowColumn = "#"  // header of the column that ties lines to grid rows
+	bulkNull      = `\N` // how NULL is written, as in COPY and LOAD DATA
+)
+
+// bulkEdit opens the visual selection (or the current cell) in $EDITOR as CSV,
+// or TSV when sep is a tab. The first column holds the grid row number:
+// changed values become UPDATEs, lines with an empty row number INSERTs and
+// removed lines DELETEs. NULL is written \N, and a value that is \N itself
+// \\N. The statements are staged and shown for review before anything is
+// applied.
+func (m Model) bulkEdit(sep rune) (tea.Model, tea.Cmd) {
+	if m.tableData == nil || m.tableData.Connection == nil || m.tableData.TableName == "" {
+		return m, m.setError("Cannot edit: table name unknown")
+	}
+	if m.numRows() == 0 {
+		return m, nil
+	}
+
+	minRow, maxRow, minCol, maxCol := m.getSelectionBounds()
+	for row := minRow; row <= maxRow; row++ {
+		if m.pendingRowChange(row) != nil {
+			return m, m.setError(fmt.Sprintf("Row %d is staged for insert or delete, commit or discard it first", row+1))
+		}
+	}
+	m.visualMode = false
+	m.loadSchema()
+
+	pattern := "pam-bulk-*.csv"
+	if sep == '\t' {
+		pattern = "pam-bulk-*.tsv"
+	}
+	tmpfile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return m, m.setError(fmt.Sprintf("Bulk edit failed: %v", err))
+	}
+	tmpfilePath := tmpfile.Name()
+	defer os.Remove(tmpfilePath)
+
+	w := csv.NewWriter(tmpfile)
+	w.Comma = sep
+	w.WriteAll(m.bulkRecords(minRow, maxRow, minCol, maxCol))
+	tmpfile.Close()
+	if err := w.Error(); err != nil {
+		return m, m.setError(fmt.Sprintf("Bulk edit failed: %v", err))
+	}
+
+	if err := runEditor(tmpfilePath); err != nil {
+		return m, m.setError(fmt.Sprintf("Error running editor: %v", err))
+	}
+	data, err := os.ReadFile(tmpfilePath)
+	if err != nil {
+		return m, m.setError(fmt.Sprintf("Bulk edit failed: %v", err))
+	}
+
+	plan, err := m.diffBulkFile(data, sep, minRow, maxRow, minCol, maxCol)
+	if err != nil {
+		return m, m.setError(fmt.Sprintf("Bulk edit not applied: %v", err))
+	}
+	if plan.empty() {
+		return m, m.setSuccess("No changes")
+	}
+	if err := m.stageBulkPlan(plan, maxRow+1, minCol, maxCol); err != nil {
+		return m, m.setError(fmt.Sprintf("Bulk edit not applied: %v", err))
+	}
+
+	m.reviewMode = true
+	m.reviewOffset = 0
+	return m, nil
+}
+
+func (m Model) bulkRecords(minRow, maxRow, minCol, maxCol int) [][]string {
+	header := []string{bulkRowColumn}
+	header = append(header, m.tableData.Columns[minCol:maxCol+1]...)
+	records := [][]string{header}
+	for row := minRow; row <= maxRow; row++ {
+		record := []string{strconv.Itoa(row + 1)}
+		for col := minCol; col <= maxCol; col++ {
+			record = append(record, bulkText(m.tableData.Rows[row][col]))
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// bulkText writes a cell for the file. A value that is \N behind any number
+// of backslashes gets one more, so that only NULL reads back as NULL.
+func bulkText(cell db.Cell) string {
+	if cell.RawValue == nil {
+		return bulkNull
+	}
+	if escapesNull(cell.Value) {
+		return `\` + cell.Value
+	}
+	return cell.Value
+}
+
+func bulkValue(text string) any {
+	if text == bulkNull {
+		return nil
+	}
+	if escapesNull(text) {
+		return text[1:]
+	}
+	return text
+}
+
+// escapesNull reports whether s is bulkNull behind zero or more extra
+// backslashes.
+func escapesNull(s string) bool {
+	body, ok := strings.CutSuffix(s, "N")
+	return ok && body != "" && strings.Trim(body, `\`) == ""
+}
+
+type bulkCellEdit struct {
+	row, col int
+	value    any
+}
+
+type bulkPlan struct {
+	edits   []bulkCellEdit
+	inserts [][]any // values for the selected columns
+	deletes []int
+}
+
+func (p bulkPlan) empty() bool {
+	return len(p.edits) == 0 && len(p.inserts) == 0 && len(p.deletes) == 0
+}
+
+// diffBulkFile compares the edited file with the grid. The whole file is
+// checked before anything changes, so a typo on the last line can't leave
+// half an edit behind.
+func (m Model) diffBulkFile(data []byte, sep rune, minRow, maxRow, minCol, maxCol int) (bulkPlan, error) {
+	var plan bulkPlan
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = sep
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return plan, err
+	}
+	if len(records) == 0 {
+		return plan, fmt.Errorf("file is empty, delete rows with D instead")
+	}
+
+	header := records[0]
+	want := m.bulkRecords(minRow, minRow, minCol, maxCol)[0]
+	if strings.Join(header, "\x00") != strings.Join(want, "\x00") {
+		return plan, fmt.Errorf("header changed, expected %s", strings.Join(want, string(sep)))
+	}
+
+	seen := make(map[int]bool)
+	for i, record := range records[1:] {
+		line := i + 2
+		if len(record) != len(header) {
+			return plan, fmt.Errorf("line %d: expected %d fields, got %d", line, len(header), len(record))
+		}
+
+		values := record[1:]
+		rowNum := strings.TrimSpace(record[0])
+		if rowNum == "" {
+			insert := make([]any, len(values))
+			for j, v := range values {
+				insert[j] = bulkValue(v)
+			}
+			plan.inserts = append(plan.inserts, insert)
+			continue
+		}
+
+		n, err := strconv.Atoi(rowNum)
+		row := n - 1
+		if err != nil || row < minRow || row > maxRow {
+			return plan, fmt.Errorf("line %d: %q is not a row of the selection", line, rowNum)
+		}
+		if seen[row] {
+			return plan, fmt.Errorf("line %d: row %d appears twice", line, n)
+		}
+		seen[row] = true
+
+		for j, v := range values {
+			col := minCol + j
+			if v != bulkText(m.tableData.Rows[row][col]) {
+				plan.edits = append(plan.edits, bulkCellEdit{row: row, col: col, value: bulkValue(v)})
+			}
+		}
+	}
+
+	for row := minRow; row <= maxRow; row++ {
+		if !seen[row] {
+			plan.deletes = append(plan.deletes, row)
+		}
+	}
+	return plan, nil
+}
+
+// stageBulkPlan turns a plan into staged changes, switching staging on for
+// the review if it was off. New rows go in at insertAt.
+func (m *Model) stageBulkPlan(plan bulkPlan, insertAt, minCol, maxCol int) error {
+	before := len(m.pending)
+	wasStaging := m.staging
+	m.staging = true
+
+	err := func() error {
+		for _, e := range plan.edits {
+			if err := m.writeCell(e.row, e.col, e.value); err != nil {
+				return fmt.Errorf("row %d: %w", e.row+1, err)
+			}
+		}
+		for _, row := range plan.deletes {
+			change, err := m.deleteChange(row)
+			if err != nil {
+				return err
+			}
+			m.pending = append(m.pending, change)
+		}
+
+		var columns []string
+		for col := minCol; col <= maxCol; col++ {
+			name := m.tableData.Columns[col]
+			if m.tableSchema != nil {
+				if info, ok := m.tableSchema.Column(name); ok {
+					name = info.Name
+				}
+			}
+			columns = append(columns, name)
+		}
+		for i, values := range plan.inserts {
+			pos := insertAt + i
+			cells := m.blankRow(pos)
+			for j, v := range values {
+				setCellValue(&cells[minCol+j], v)
+			}
+			change, err := m.insertChange(pos, columns, values, cells)
+			if err != nil {
+				return err
+			}
+			m.insertGridRow(pos, cells)
+			m.pending = append(m.pending, change)
+		}
+		return nil
+	}()
+	if err != nil {
+		m.discardPendingFrom(before)
+		m.staging = wasStaging
+		return err
+	}
+
+	m.unstaged = nil
+	if !wasStaging {
+		m.autoStaged = true
+	}
+	return nil
+}
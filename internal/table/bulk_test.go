@@ -0,0 +1,34 @@
+package table
+
+import (
+	"testing"
+
+	"github.com/eduardofuncao/pam/internal/db"
+)
+
+func TestBulkTextRoundTrip(t *testing.T) {
+	tests := []struct {
+		value any
+		text  string
+	}{
+		{nil, `\N`},
+		{"plain", "plain"},
+		{`\N`, `\\N`},
+		{`\\N`, `\\\N`},
+		{"N", "N"},
+		{`a\N`, `a\N`},
+		{`\n`, `\n`},
+	}
+	for _, tt := range tests {
+		cell := db.Cell{RawValue: tt.value}
+		if tt.value != nil {
+			cell.Value = tt.value.(string)
+		}
+		if got := bulkText(cell); got != tt.text {
+			t.Errorf("bulkText(%q) = %q, want %q", tt.value, got, tt.text)
+		}
+		if got := bulkValue(tt.text); got != tt.value {
+			t.Errorf("bulkValue(%q) = %q, want %q", tt.text, got, tt.value)
+		}
+	}
+}
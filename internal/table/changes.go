@@ -0,0 +1,331 @@
+package table
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/eduardofuncao/pam/internal/db"
+	"github.com/eduardofuncao/pam/internal/editor"
+)
+
+type changeKind int
+
+const (
+	changeUpdate changeKind = iota
+	changeInsert
+	changeDelete
+)
+
+// pendingChange is a statement waiting in the staging area. It keeps the
+// cell it replaced (or the row it adds) so discarding can put the grid back
+// the way it was.
+type pendingChange struct {
+	kind    changeKind
+	sql     string
+	args    []any
+	row     int
+	col     int
+	oldCell db.Cell
+	newCell db.Cell
+
+	// Inserts only: the grid row, the grid columns to read back once the row
+	// exists and whether sql returns them itself.
+	cells    db.Row
+	readBack []int
+	returns  bool
+	returned []any
+	insertID int64
+}
+
+// writeCell sets a cell to value (nil for NULL). Outside staging mode the
+// UPDATE runs right away; in staging mode it is queued for review.
+func (m *Model) writeCell(row, col int, value any) error {
+	if pending := m.pendingRowChange(row); pending != nil {
+		if pending.kind == changeDelete {
+			return fmt.Errorf("row is staged for delete")
+		}
+		return fmt.Errorf("row is staged for insert, commit it before editing")
+	}
+	cell := &m.tableData.Rows[row][col]
+	m.loadSchema()
+	query, args, err := m.buildUpdateQuery(cell, value)
+	if err != nil {
+		return err
+	}
+
+	before := *cell
+	if !m.staging {
+		if err := m.execSingleRow(query, args); err != nil {
+			return err
+		}
+	}
+
+	setCellValue(cell, value)
+	if m.staging {
+		m.pending = append(m.pending, pendingChange{
+			kind:    changeUpdate,
+			sql:     query,
+			args:    args,
+			row:     row,
+			col:     col,
+			oldCell: before,
+			newCell: *cell,
+		})
+		m.unstaged = nil
+	} else {
+		m.recordEdit(cellEdit{row: row, col: col, before: before, after: *cell})
+	}
+	return nil
+}
+
+func setCellValue(cell *db.Cell, value any) {
+	cell.RawValue = value
+	cell.Value = db.FormatValue(value)
+}
+
+func (m Model) toggleStaging() (tea.Model, tea.Cmd) {
+	if m.staging && len(m.pending) > 0 {
+		return m, m.setError(fmt.Sprintf("%d staged changes: commit or discard them first (w)", len(m.pending)))
+	}
+	m.staging = !m.staging
+	if m.staging {
+		return m, m.setSuccess("Staging on: changes are queued until committed")
+	}
+	return m, m.setSuccess("Staging off: changes are applied immediately")
+}
+
+func (m Model) openReview() (tea.Model, tea.Cmd) {
+	if len(m.pending) == 0 {
+		return m, m.setError("No staged changes")
+	}
+	m.reviewMode = true
+	m.reviewOffset = 0
+	return m, nil
+}
+
+func (m Model) handleReviewKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.reviewMode = false
+	case "c", "enter":
+		return m.commitPending()
+	case "x":
+		n := len(m.pending)
+		m.discardPending()
+		m.reviewMode = false
+		m.endAutoStaging()
+		return m, m.setSuccess(fmt.Sprintf("Discarded %d changes", n))
+	case "j", "down":
+		m.reviewOffset++
+	case "k", "up":
+		if m.reviewOffset > 0 {
+			m.reviewOffset--
+		}
+	}
+	return m, nil
+}
+
+// commitPending applies every staged change in a single transaction. Each
+// statement must change exactly one row, otherwise nothing is applied.
+func (m Model) commitPending() (tea.Model, tea.Cmd) {
+	if len(m.pending) == 0 {
+		m.reviewMode = false
+		return m, nil
+	}
+
+	ctx, cancel := m.statementContext()
+	defer cancel()
+	tx, err := m.tableData.Connection.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return m, m.setError(fmt.Sprintf("Commit failed: %v", err))
+	}
+	for i := range m.pending {
+		if err := m.pending[i].apply(ctx, tx); err != nil {
+			tx.Rollback()
+			m.reviewMode = false
+			return m, m.setError(fmt.Sprintf("Commit failed at change %d, nothing applied: %v", i+1, err))
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return m, m.setError(fmt.Sprintf("Commit failed: %v", err))
+	}
+
+	// Committed cell updates become regular edits that undo can revert
+	for _, change := range m.pending {
+		switch change.kind {
+		case changeUpdate:
+			m.recordEdit(cellEdit{row: change.row, col: change.col, before: change.oldCell, after: change.newCell})
+		case changeInsert:
+			m.fillInsertedRow(change)
+		}
+	}
+
+	n := len(m.pending)
+	committed := m.pending
+	m.pending = nil
+	m.unstaged = nil
+	m.reviewMode = false
+	m.removeDeletedRows(committed)
+	m.endAutoStaging()
+	return m, m.setSuccess(fmt.Sprintf("Committed %d changes", n))
+}
+
+// apply runs the change inside tx. Inserts also keep what the database
+// reports back about the new row.
+func (c *pendingChange) apply(ctx context.Context, tx *sql.Tx) error {
+	if c.returns {
+		values := make([]any, len(c.readBack))
+		ptrs := make([]any, len(values))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := tx.QueryRowContext(ctx, c.sql, c.args...).Scan(ptrs...); err != nil {
+			return err
+		}
+		c.returned = values
+		return nil
+	}
+
+	result, err := tx.ExecContext(ctx, c.sql, c.args...)
+	if err != nil {
+		return err
+	}
+	if c.kind == changeInsert {
+		if id, err := result.LastInsertId(); err == nil {
+			c.insertID = id
+		}
+	}
+	return checkRowsAffected(result, 1)
+}
+
+// applyNow runs a single change in its own transaction.
+func (m Model) applyNow(change *pendingChange) error {
+	ctx, cancel := m.statementContext()
+	defer cancel()
+	tx, err := m.tableData.Connection.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := change.apply(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// discardPending drops every staged change and restores the grid, newest
+// change first.
+func (m *Model) discardPending() {
+	m.discardPendingFrom(0)
+	m.unstaged = nil
+}
+
+// discardPendingFrom drops the staged changes from index n on.
+func (m *Model) discardPendingFrom(n int) {
+	dropped := m.pending[n:]
+	m.pending = m.pending[:n]
+	for i := len(dropped) - 1; i >= 0; i-- {
+		change := dropped[i]
+		switch change.kind {
+		case changeUpdate:
+			m.tableData.Rows[change.row][change.col] = change.oldCell
+		case changeInsert:
+			m.removeGridRow(change.row)
+		}
+	}
+}
+
+// endAutoStaging turns staging back off once changes that switched it on by
+// themselves (a bulk edit) are committed or discarded.
+func (m *Model) endAutoStaging() {
+	if m.autoStaged && len(m.pending) == 0 {
+		m.staging = false
+		m.autoStaged = false
+	}
+}
+
+func (m Model) isCellPending(row, col int) bool {
+	for _, change := range m.pending {
+		if change.row != row {
+			continue
+		}
+		if change.kind != changeUpdate || change.col == col {
+			return true
+		}
+	}
+	return false
+}
+
+func (m Model) renderReview() string {
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorHeader)).Bold(true)
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorNull))
+	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorKeyHighlight)).Bold(true)
+
+	var lines []string
+	for i, change := range m.pending {
+		lines = append(lines, titleStyle.Render(fmt.Sprintf("%d.", i+1))+" "+editor.HighlightSQL(change.sql))
+		if len(change.args) > 0 {
+			lines = append(lines, hintStyle.Render("   -- args: "+formatArgs(change.args)))
+		}
+	}
+
+	visible := max(m.height-4, 1)
+	offset := min(m.reviewOffset, max(len(lines)-visible, 0))
+	end := min(offset+visible, len(lines))
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Review %d staged changes", len(m.pending))))
+	b.WriteString(hintStyle.Render("  " + m.pendingSummary()))
+	b.WriteString("\n\n")
+	b.WriteString(strings.Join(lines[offset:end], "\n"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("%s%s  %s%s  %s%s  %s",
+		keyStyle.Render("c"), hintStyle.Render("ommit"),
+		keyStyle.Render("x"), hintStyle.Render(" discard"),
+		keyStyle.Render("esc"), hintStyle.Render(" back"),
+		hintStyle.Render("jk: scroll")))
+	return b.String()
+}
+
+// pendingSummary counts staged changes by kind, e.g. "3 updates, 1 insert".
+func (m Model) pendingSummary() string {
+	counts := make(map[changeKind]int)
+	for _, change := range m.pending {
+		counts[change.kind]++
+	}
+	var parts []string
+	for _, k := range []struct {
+		kind changeKind
+		name string
+	}{{changeUpdate, "update"}, {changeInsert, "insert"}, {changeDelete, "delete"}} {
+		switch n := counts[k.kind]; n {
+		case 0:
+		case 1:
+			parts = append(parts, "1 "+k.name)
+		default:
+			parts = append(parts, fmt.Sprintf("%d %ss", n, k.name))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatArgs(args []any) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case nil:
+			parts[i] = "NULL"
+		case string:
+			parts[i] = "'" + strings.ReplaceAll(v, "'", "''") + "'"
+		case []byte:
+			parts[i] = "'" + strings.ReplaceAll(string(v), "'", "''") + "'"
+		default:
+			parts[i] = fmt.Sprintf("%v", v)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
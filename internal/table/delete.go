@@ -0,0 +1,130 @@
+package table
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eduardofuncao/pam/internal/db"
+)
+
+// enterRowDeleteConfirm asks before deleting the current row, or every row
+// of the visual selection.
+func (m Model) enterRowDeleteConfirm() (tea.Model, tea.Cmd) {
+	if m.tableData == nil || m.tableData.Connection == nil || m.tableData.TableName == "" {
+		return m, m.setError("Cannot delete: table name unknown")
+	}
+	if m.numRows() == 0 {
+		return m, nil
+	}
+
+	m.loadSchema()
+	minRow, maxRow, _, _ := m.getSelectionBounds()
+	count := maxRow - minRow + 1
+	prompt := "Delete 1 row?"
+	if count > 1 {
+		prompt = fmt.Sprintf("Delete %d rows?", count)
+	}
+	if m.rowKey(minRow) == nil {
+		prompt += " (no key, rows are matched on every column)"
+	}
+
+	m.confirmMode = true
+	m.confirmAction = "delete_rows"
+	m.confirmPrompt = prompt
+	return m, nil
+}
+
+// deleteRows deletes the selected rows by key. Outside staging mode all
+// DELETEs run in one transaction and each must remove exactly one row; in
+// staging mode they are queued and the rows stay visible until commit.
+func (m Model) deleteRows() (tea.Model, tea.Cmd) {
+	minRow, maxRow, _, _ := m.getSelectionBounds()
+	m.visualMode = false
+
+	var changes []pendingChange
+	for row := minRow; row <= maxRow; row++ {
+		if pending := m.pendingRowChange(row); pending != nil {
+			if pending.kind == changeDelete {
+				continue
+			}
+			return m, m.setError(fmt.Sprintf("Row %d is staged for insert, commit or discard it first", row+1))
+		}
+		change, err := m.deleteChange(row)
+		if err != nil {
+			return m, m.setError(fmt.Sprintf("Delete failed: %v", err))
+		}
+		changes = append(changes, change)
+	}
+	if len(changes) == 0 {
+		return m, m.setError("Rows are already staged for delete")
+	}
+
+	if m.staging {
+		m.pending = append(m.pending, changes...)
+		m.unstaged = nil
+		return m, m.setSuccess(fmt.Sprintf(msgStagedFmt, len(m.pending)))
+	}
+
+	ctx, cancel := m.statementContext()
+	defer cancel()
+	tx, err := m.tableData.Connection.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return m, m.setError(fmt.Sprintf("Delete failed: %v", err))
+	}
+	for i := range changes {
+		if err := changes[i].apply(ctx, tx); err != nil {
+			tx.Rollback()
+			return m, m.setError(fmt.Sprintf("Delete failed on row %d, nothing deleted: %v", changes[i].row+1, err))
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return m, m.setError(fmt.Sprintf("Delete failed: %v", err))
+	}
+
+	m.removeDeletedRows(changes)
+	if len(changes) == 1 {
+		return m, m.setSuccess("Deleted 1 row")
+	}
+	return m, m.setSuccess(fmt.Sprintf("Deleted %d rows", len(changes)))
+}
+
+// deleteChange builds the DELETE of a single grid row.
+func (m Model) deleteChange(row int) (pendingChange, error) {
+	table, err := db.QuoteName(m.tableData.Connection.GetDialect(), m.tableData.TableName)
+	if err != nil {
+		return pendingChange{}, err
+	}
+	where, args := m.buildRowFilter(row, -1, 1)
+	return pendingChange{
+		kind: changeDelete,
+		sql:  fmt.Sprintf("DELETE FROM %s WHERE %s", table, where),
+		args: args,
+		row:  row,
+	}, nil
+}
+
+// removeDeletedRows drops the rows of applied deletes from the grid, last row
+// first so earlier indexes stay valid.
+func (m *Model) removeDeletedRows(changes []pendingChange) {
+	var rows []int
+	for _, change := range changes {
+		if change.kind == changeDelete {
+			rows = append(rows, change.row)
+		}
+	}
+	slices.Sort(rows)
+	for i := len(rows) - 1; i >= 0; i-- {
+		m.removeGridRow(rows[i])
+	}
+}
+
+// pendingRowChange returns the staged insert or delete of a row, if any.
+func (m Model) pendingRowChange(row int) *pendingChange {
+	for i := range m.pending {
+		if m.pending[i].row == row && m.pending[i].kind != changeUpdate {
+			return &m.pending[i]
+		}
+	}
+	return nil
+}
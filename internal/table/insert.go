@@ -0,0 +1,420 @@
+package table
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/eduardofuncao/pam/internal/db"
+	"github.com/mattn/go-runewidth"
+)
+
+// defaultCellValue is shown for columns of a new row that the database fills
+// in and that could not be read back.
+const defaultCellValue = "DEFAULT"
+
+// fieldState is what an insert form field sends for its column.
+type fieldState int
+
+const (
+	fieldDefault fieldState = iota // column left out, the engine fills it in
+	fieldNull
+	fieldValue // the typed text, which may be an empty string
+)
+
+type formField struct {
+	column  string
+	colType string
+	def     sql.NullString
+	state   fieldState
+	input   textinput.Model
+}
+
+type insertForm struct {
+	fields []formField
+	focus  int
+	row    int // grid position the new row goes to
+}
+
+func (f *insertForm) focusField(i int) {
+	if len(f.fields) == 0 {
+		return
+	}
+	f.fields[f.focus].input.Blur()
+	f.focus = (i + len(f.fields)) % len(f.fields)
+	f.fields[f.focus].input.Focus()
+}
+
+func (f *insertForm) field(column string) *formField {
+	for i := range f.fields {
+		if strings.EqualFold(f.fields[i].column, column) {
+			return &f.fields[i]
+		}
+	}
+	return nil
+}
+
+// openInsertForm starts a new row below (o) or above (O) the cursor, with one
+// field per table column. Columns with a default start as DEFAULT, and so do
+// key columns without one since identity and auto-increment columns have no
+// catalog default; nullable columns start as NULL.
+func (m Model) openInsertForm(below bool) (tea.Model, tea.Cmd) {
+	if m.tableData == nil || m.tableData.Connection == nil || m.tableData.TableName == "" {
+		return m, m.setError("Cannot insert: table name unknown")
+	}
+
+	pos := 0
+	if m.numRows() > 0 {
+		pos = m.selectedRow
+		if below {
+			pos++
+		}
+	}
+
+	form := &insertForm{row: pos}
+	if schema := m.loadSchema(); schema != nil {
+		for _, col := range schema.Columns {
+			state := fieldValue
+			switch {
+			case col.Default.Valid, schema.IsPrimaryKey(col.Name):
+				state = fieldDefault
+			case col.Nullable:
+				state = fieldNull
+			}
+			form.fields = append(form.fields, newFormField(col.Name, col.Type, col.Default, state))
+		}
+	} else {
+		// Without the catalog only the result columns are known
+		for i, name := range m.tableData.Columns {
+			form.fields = append(form.fields, newFormField(name, m.tableData.ColumnType(i), sql.NullString{}, fieldDefault))
+		}
+	}
+	form.focusField(0)
+
+	m.insertForm = form
+	return m, textinput.Blink
+}
+
+func newFormField(column, colType string, def sql.NullString, state fieldState) formField {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.CharLimit = 0
+	ti.Width = 50
+	return formField{column: column, colType: colType, def: def, state: state, input: ti}
+}
+
+func (m Model) handleInsertFormKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	form := m.insertForm
+	field := &form.fields[form.focus]
+
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.insertForm = nil
+		return m, nil
+	case "enter":
+		return m.submitInsert()
+	case "tab", "down":
+		form.focusField(form.focus + 1)
+		return m, nil
+	case "shift+tab", "up":
+		form.focusField(form.focus - 1)
+		return m, nil
+	case "ctrl+n":
+		if field.state == fieldNull {
+			field.state = fieldValue
+		} else {
+			field.state = fieldNull
+		}
+		return m, nil
+	case "ctrl+d":
+		field.state = fieldDefault
+		return m, nil
+	}
+
+	// Typing into a NULL or DEFAULT field switches it to a value
+	if field.state != fieldValue {
+		if msg.Type != tea.KeyRunes && msg.Type != tea.KeySpace {
+			return m, nil
+		}
+		field.state = fieldValue
+	}
+	var cmd tea.Cmd
+	field.input, cmd = field.input.Update(msg)
+	return m, cmd
+}
+
+// submitInsert builds the INSERT from the form. Outside staging mode it runs
+// right away and the grid shows what the database stored, generated keys
+// included; in staging mode it is queued like any other change.
+func (m Model) submitInsert() (tea.Model, tea.Cmd) {
+	form := m.insertForm
+
+	var columns []string
+	var args []any
+	for _, f := range form.fields {
+		switch f.state {
+		case fieldNull:
+			columns = append(columns, f.column)
+			args = append(args, nil)
+		case fieldValue:
+			columns = append(columns, f.column)
+			args = append(args, f.input.Value())
+		}
+	}
+
+	change, err := m.insertChange(form.row, columns, args, m.formRow(form))
+	if err != nil {
+		return m, m.setError(fmt.Sprintf("Insert failed: %v", err))
+	}
+
+	if m.staging {
+		m.insertForm = nil
+		m.insertGridRow(change.row, change.cells)
+		m.pending = append(m.pending, change)
+		m.unstaged = nil
+		m.moveTo(change.row, m.selectedCol)
+		return m, m.setSuccess(fmt.Sprintf(msgStagedFmt, len(m.pending)))
+	}
+
+	// On failure the form stays open so the values can be fixed
+	if err := m.applyNow(&change); err != nil {
+		return m, m.setError(fmt.Sprintf("Insert failed: %v", err))
+	}
+	m.insertForm = nil
+	m.insertGridRow(change.row, change.cells)
+	m.fillInsertedRow(change)
+	m.moveTo(change.row, m.selectedCol)
+	return m, m.setSuccess("Row inserted")
+}
+
+// insertChange builds the insert of a row that sets columns (exact names) to
+// args. cells is the row as the grid shows it until the database fills in
+// the rest.
+func (m Model) insertChange(pos int, columns []string, args []any, cells db.Row) (pendingChange, error) {
+	// Grid columns that are real table columns can be read back after insert
+	var readBack []int
+	var returning []string
+	if m.tableSchema != nil {
+		for i, name := range m.tableData.Columns {
+			if col, ok := m.tableSchema.Column(name); ok {
+				readBack = append(readBack, i)
+				returning = append(returning, col.Name)
+			}
+		}
+	}
+
+	query, returns, err := db.InsertSQL(m.tableData.Connection.GetDialect(), m.tableData.TableName, columns, returning)
+	if err != nil {
+		return pendingChange{}, err
+	}
+	return pendingChange{
+		kind:     changeInsert,
+		sql:      query,
+		args:     args,
+		row:      pos,
+		cells:    cells,
+		readBack: readBack,
+		returns:  returns,
+	}, nil
+}
+
+// blankRow is a new grid row whose values are all left to the database.
+func (m Model) blankRow(pos int) db.Row {
+	row := make(db.Row, m.numCols())
+	for i := range row {
+		row[i] = db.Cell{Value: defaultCellValue, ColumnIndex: i, RowIndex: pos}
+	}
+	return row
+}
+
+// formRow lays the form values out as a grid row.
+func (m Model) formRow(form *insertForm) db.Row {
+	row := m.blankRow(form.row)
+	for i := range row {
+		cell := &row[i]
+		if f := form.field(m.tableData.Columns[i]); f != nil {
+			switch f.state {
+			case fieldNull:
+				setCellValue(cell, nil)
+			case fieldValue:
+				setCellValue(cell, f.input.Value())
+			}
+		}
+	}
+	return row
+}
+
+// fillInsertedRow replaces the entered values of an inserted row with what the
+// database stored, so generated keys and defaults show up. Engines without
+// RETURNING get the auto-increment id and a re-read by key where possible.
+func (m *Model) fillInsertedRow(change pendingChange) {
+	row := m.tableData.Rows[change.row]
+	if change.returned != nil {
+		for i, idx := range change.readBack {
+			setCellValue(&row[idx], change.returned[i])
+		}
+		return
+	}
+
+	if change.insertID > 0 && m.tableSchema != nil && len(m.tableSchema.PrimaryKey) == 1 {
+		if idx := m.columnIndex(m.tableSchema.PrimaryKey[0]); idx >= 0 && row[idx].RawValue == nil {
+			setCellValue(&row[idx], change.insertID)
+		}
+	}
+	if len(change.readBack) == 0 || m.rowKey(change.row) == nil {
+		return
+	}
+
+	names := make([]string, len(change.readBack))
+	for i, idx := range change.readBack {
+		names[i] = m.tableData.Connection.GetDialect().QuoteIdentifier(m.tableData.Columns[idx])
+	}
+	table, err := db.QuoteName(m.tableData.Connection.GetDialect(), m.tableData.TableName)
+	if err != nil {
+		return
+	}
+	where, args := m.buildRowFilter(change.row, -1, 1)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(names, ", "), table, where)
+
+	values := make([]any, len(names))
+	ptrs := make([]any, len(names))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	ctx, cancel := m.statementContext()
+	defer cancel()
+	if err := m.tableData.Connection.GetDB().QueryRowContext(ctx, query, args...).Scan(ptrs...); err != nil {
+		return
+	}
+	for i, idx := range change.readBack {
+		setCellValue(&row[idx], values[i])
+	}
+}
+
+// insertGridRow puts row at index pos and moves everything that points at
+// later rows down by one.
+func (m *Model) insertGridRow(pos int, row db.Row) {
+	rows := append(m.tableData.Rows, nil)
+	copy(rows[pos+1:], rows[pos:])
+	rows[pos] = row
+	m.tableData.Rows = rows
+	m.shiftRows(pos, 1)
+	m.gridChanged(pos)
+}
+
+// removeGridRow drops the row at pos. Undo history for that row goes with it.
+func (m *Model) removeGridRow(pos int) {
+	m.tableData.Rows = append(m.tableData.Rows[:pos], m.tableData.Rows[pos+1:]...)
+	m.undoStack = dropRow(m.undoStack, pos)
+	m.redoStack = dropRow(m.redoStack, pos)
+	m.shiftRows(pos+1, -1)
+	m.gridChanged(pos)
+	if m.selectedRow >= m.numRows() {
+		m.selectedRow = max(m.numRows()-1, 0)
+	}
+}
+
+// shiftRows moves row references at or after from by delta.
+func (m *Model) shiftRows(from, delta int) {
+	for _, changes := range [][]pendingChange{m.pending, m.unstaged} {
+		for i := range changes {
+			if changes[i].row >= from {
+				changes[i].row += delta
+			}
+		}
+	}
+	for _, edits := range [][]cellEdit{m.undoStack, m.redoStack} {
+		for i := range edits {
+			if edits[i].row >= from {
+				edits[i].row += delta
+			}
+		}
+	}
+}
+
+func dropRow(edits []cellEdit, row int) []cellEdit {
+	kept := edits[:0]
+	for _, e := range edits {
+		if e.row != row {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// gridChanged renumbers rows from pos on and refits the viewport after rows
+// were added or removed.
+func (m *Model) gridChanged(pos int) {
+	for r := pos; r < len(m.tableData.Rows); r++ {
+		for c := range m.tableData.Rows[r] {
+			m.tableData.Rows[r][c].RowIndex = r
+		}
+	}
+	m.columnWidths = calculateColumnWidths(m.tableData)
+	*m = m.handleWindowResize(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+}
+
+func (m Model) renderInsertForm() string {
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorHeader)).Bold(true)
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorNull))
+	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorKeyHighlight)).Bold(true)
+
+	form := m.insertForm
+	nameWidth, typeWidth := 0, 0
+	for _, f := range form.fields {
+		nameWidth = max(nameWidth, runewidth.StringWidth(f.column))
+		typeWidth = max(typeWidth, runewidth.StringWidth(f.colType))
+	}
+
+	var lines []string
+	for i, f := range form.fields {
+		marker := "  "
+		if i == form.focus {
+			marker = keyStyle.Render("› ")
+		}
+
+		var value string
+		switch f.state {
+		case fieldDefault:
+			value = nullStyle.Render(defaultCellValue)
+			if f.def.Valid {
+				value += hintStyle.Render(" " + f.def.String)
+			}
+		case fieldNull:
+			value = nullStyle.Render("NULL")
+		default:
+			value = f.input.View()
+		}
+
+		lines = append(lines, fmt.Sprintf("%s%s  %s  %s",
+			marker,
+			headerStyle.Render(runewidth.FillRight(f.column, nameWidth)),
+			hintStyle.Render(runewidth.FillRight(f.colType, typeWidth)),
+			value))
+	}
+
+	// Keep the focused field on screen for wide tables
+	visible := max(m.height-5, 1)
+	offset := max(form.focus-visible+1, 0)
+	end := min(offset+visible, len(lines))
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Insert into " + m.tableData.TableName))
+	b.WriteString("\n\n")
+	b.WriteString(strings.Join(lines[offset:end], "\n"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("%s %s  %s %s  %s %s  %s %s  %s %s",
+		keyStyle.Render("enter"), hintStyle.Render("insert"),
+		keyStyle.Render("tab"), hintStyle.Render("next"),
+		keyStyle.Render("ctrl+n"), hintStyle.Render("NULL"),
+		keyStyle.Render("ctrl+d"), hintStyle.Render("default"),
+		keyStyle.Render("esc"), hintStyle.Render("cancel")))
+	if m.statusMessage != "" && m.isError {
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(colorError)).Bold(true).Render(m.statusMessage))
+	}
+	return b.String()
+}
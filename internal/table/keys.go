@@ -0,0 +1,104 @@
+package table
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/eduardofuncao/pam/internal/db"
+)
+
+// loadSchema reads the schema of the table being browsed once per view. A
+// failure (views, missing privileges) leaves the schema nil and row targeting
+// falls back to matching on every column.
+func (m *Model) loadSchema() *db.TableSchema {
+	if m.schemaLoaded || m.tableData == nil || m.tableData.Connection == nil || m.tableData.TableName == "" {
+		return m.tableSchema
+	}
+	m.schemaLoaded = true
+	m.releaseStream()
+	schema, err := db.DescribeTable(m.tableData.Connection, m.tableData.TableName)
+	if err == nil {
+		m.tableSchema = schema
+	}
+	return m.tableSchema
+}
+
+// rowKey returns the result-set column indexes of the primary key, or of the
+// first unique key, when every key column is part of the result and has a
+// value in the given row. It returns nil when the row can't be identified by
+// a key.
+func (m Model) rowKey(rowIndex int) []int {
+	if m.tableSchema == nil {
+		return nil
+	}
+	for _, key := range m.tableSchema.UniqueKeys() {
+		indexes := make([]int, 0, len(key))
+		for _, keyCol := range key {
+			idx := m.columnIndex(keyCol)
+			if idx < 0 || m.tableData.Rows[rowIndex][idx].RawValue == nil {
+				indexes = nil
+				break
+			}
+			indexes = append(indexes, idx)
+		}
+		if len(indexes) > 0 {
+			return indexes
+		}
+	}
+	return nil
+}
+
+func (m Model) columnIndex(name string) int {
+	for i, col := range m.tableData.Columns {
+		if strings.EqualFold(col, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// execSingleRow runs a statement that must change exactly one row. It runs in
+// a transaction that is rolled back when zero or several rows would change.
+func (m Model) execSingleRow(query string, args []any) error {
+	ctx, cancel := m.statementContext()
+	defer cancel()
+	tx, err := m.tableData.Connection.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := checkRowsAffected(result, 1); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// statementContext bounds edits by the connection's query timeout, so a row
+// lock held elsewhere can't freeze the view.
+func (m Model) statementContext() (context.Context, context.CancelFunc) {
+	m.releaseStream()
+	ctx, cancel, _ := db.WithQueryTimeout(context.Background(), m.tableData.Connection)
+	return ctx, cancel
+}
+
+func checkRowsAffected(result sql.Result, expected int64) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		// Driver can't tell; trust the key-based WHERE clause
+		return nil
+	}
+	switch {
+	case affected == 0:
+		return fmt.Errorf("no row matched; it may have been changed or deleted, refresh and retry")
+	case affected != expected:
+		return fmt.Errorf("would change %d rows instead of %d; refusing (no primary or unique key to target the row)", affected, expected)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package table
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/eduardofuncao/pam/internal/db"
+)
+
+// RowMark colors a row of a marked view.
+type RowMark int
+
+const (
+	MarkNone RowMark = iota
+	MarkAdded
+	MarkRemoved
+	MarkChanged
+)
+
+// Marks color the rows of a view, and the cells that changed within them.
+type Marks struct {
+	Rows    []RowMark // one per row
+	Changed [][]bool  // per row and column, for MarkChanged rows
+}
+
+// ShowMarked shows rows read-only, colored by marks, with status in the
+// footer until another message replaces it.
+func ShowMarked(tableData *db.TableData, marks Marks, status string) error {
+	model := New(tableData, 0, nil)
+	model.pickKeys = []PickKey{}
+	model.marks = &marks
+	model.statusMessage = status
+
+	_, err := tea.NewProgram(model).Run()
+	return err
+}
+
+// markStyle returns the style of a cell of a marked row, ok false when the
+// row isn't marked.
+func (m Model) markStyle(row, col int) (style lipgloss.Style, ok bool) {
+	if m.marks == nil || row >= len(m.marks.Rows) {
+		return style, false
+	}
+	switch m.marks.Rows[row] {
+	case MarkAdded:
+		return addedStyle, true
+	case MarkRemoved:
+		return removedStyle, true
+	case MarkChanged:
+		if row < len(m.marks.Changed) && col < len(m.marks.Changed[row]) && m.marks.Changed[row][col] {
+			return changedCellStyle, true
+		}
+		return changedStyle, true
+	}
+	return style, false
+}
@@ -1,6 +1,7 @@
 package table
 
 import (
+	"context"
 	"strings"
 	"time"
 
@@ -17,7 +18,9 @@ const (
 	verticalReserved  = 9  // Reserved vertical space for header/footer
 )
 
-type CommandExecutor func(args []string) (*db.TableData, error)
+// CommandExecutor runs a pam command line from the TUI. Cancelling ctx stops
+// the statement it runs.
+type CommandExecutor func(ctx context.Context, args []string) (*db.TableData, error)
 
 type Model struct {
 	width           int
@@ -41,9 +44,31 @@ type Model struct {
 	commandInput    textinput.Model
 	queries         map[string]string
 	originalSQL     string
+	originalParams  map[string]string
 	executeCommand  CommandExecutor
 	confirmMode     bool
 	confirmAction   string
+	confirmPrompt   string
+	tableSchema     *db.TableSchema
+	schemaLoaded    bool
+	staging         bool
+	autoStaged      bool
+	pending         []pendingChange
+	reviewMode      bool
+	reviewOffset    int
+	quitArmed       bool
+	undoStack       []cellEdit
+	redoStack       []cellEdit
+	unstaged        []pendingChange
+	insertForm      *insertForm
+	running         bool
+	cancelRun       context.CancelFunc
+	cancelled       bool
+	runStarted      time.Time
+	fetching        bool
+	pickKeys        []PickKey
+	picked          string
+	marks           *Marks
 }
 
 type blinkMsg struct{}
@@ -57,8 +82,10 @@ func New(tableData *db.TableData, elapsed time.Duration, cmdExec CommandExecutor
 	ti.Width = 80
 
 	originalSQL := ""
+	var originalParams map[string]string
 	if tableData != nil {
 		originalSQL = tableData.SQL
+		originalParams = tableData.Params
 	}
 
 	return Model{
@@ -74,6 +101,7 @@ func New(tableData *db.TableData, elapsed time.Duration, cmdExec CommandExecutor
 		commandInput:    ti,
 		queries:         make(map[string]string),
 		originalSQL:     originalSQL,
+		originalParams:  originalParams,
 		executeCommand:  cmdExec,
 	}
 }
@@ -110,6 +138,28 @@ func (m Model) getCurrentCell() *db.Cell {
 	return m.getCell(m.selectedRow, m.selectedCol)
 }
 
+// setTableData switches the model to a new view and resets per-view state.
+func (m *Model) setTableData(tableData *db.TableData) {
+	if m.tableData != nil && m.tableData != tableData {
+		m.tableData.Close()
+	}
+	m.tableData = tableData
+	m.fetching = false
+	m.columnWidths = calculateColumnWidths(tableData)
+	m.selectedRow = 0
+	m.selectedCol = 0
+	m.offsetX = 0
+	m.offsetY = 0
+	m.tableSchema = nil
+	m.schemaLoaded = false
+	// Undone changes and the staging mode belong to the table being left
+	m.undoStack = nil
+	m.redoStack = nil
+	m.unstaged = nil
+	m.staging = false
+	m.autoStaged = false
+}
+
 // Status message helpers
 func (m *Model) setStatus(msg string, isError bool, autoClear bool) tea.Cmd {
 	m.statusMessage = msg
@@ -164,12 +214,7 @@ func calculateColumnWidths(tableData *db.TableData) []int {
 	for colIdx, colName := range tableData.Columns {
 		headerWidth := runewidth.StringWidth(colName)
 
-		var colType string
-		if len(tableData.Rows) > 0 && colIdx < len(tableData.Rows[0]) {
-			colType = tableData.Rows[0][colIdx].ColumnType
-		}
-
-		typeMax := getTypeMaxWidth(colType)
+		typeMax := getTypeMaxWidth(tableData.ColumnType(colIdx))
 
 		maxContentWidth := headerWidth
 		for _, row := range tableData.Rows {
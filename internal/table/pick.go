@@ -0,0 +1,47 @@
+package table
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eduardofuncao/pam/internal/db"
+)
+
+// PickKey is a key that picks the selected row of a Pick view.
+type PickKey struct {
+	Key  string // as reported by tea.KeyMsg, e.g. "enter"
+	Hint string // shown in the footer next to the key
+}
+
+// Pick shows rows that aren't backed by a table, read-only, and returns once
+// one of keys is pressed with the key and the index of the selected row. key
+// is "" when the view was quit.
+func Pick(tableData *db.TableData, keys []PickKey) (key string, row int, err error) {
+	model := New(tableData, 0, nil)
+	model.pickKeys = keys
+
+	result, err := tea.NewProgram(model).Run()
+	if err != nil {
+		return "", 0, err
+	}
+	picked := result.(Model)
+	return picked.picked, picked.selectedRow, nil
+}
+
+// pickBlocked are the keys that change data or run commands, which a pick
+// view has no use for.
+var pickBlocked = map[string]bool{
+	"e": true, "E": true, "T": true, "d": true, "D": true, "o": true, "O": true,
+	"s": true, "w": true, "u": true, "ctrl+r": true, ";": true,
+}
+
+// handlePickKey handles the keys of a pick view; handled is false for the
+// ones left to the table view, like navigation and yanking.
+func (m Model) handlePickKey(msg tea.KeyMsg) (_ tea.Model, _ tea.Cmd, handled bool) {
+	key := msg.String()
+	for _, pk := range m.pickKeys {
+		if pk.Key == key && m.numRows() > 0 {
+			m.picked = key
+			return m, tea.Quit, true
+		}
+	}
+	return m, nil, pickBlocked[key]
+}
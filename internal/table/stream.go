@@ -0,0 +1,79 @@
+package table
+
+import (
+	"fmt"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eduardofuncao/pam/internal/db"
+)
+
+// pageMsg carries rows read from a view's stream in the background.
+type pageMsg struct {
+	tableData *db.TableData
+	rows      []db.Row
+	err       error
+}
+
+// fetchMoreRows starts reading the next page once the cursor comes within
+// half a page of the last loaded row, so scrolling rarely has to wait.
+func (m Model) fetchMoreRows() (Model, tea.Cmd) {
+	tableData := m.tableData
+	if tableData == nil || tableData.Stream == nil || m.fetching {
+		return m, nil
+	}
+	if m.selectedRow < m.numRows()-db.PageSize/2 {
+		return m, nil
+	}
+
+	m.fetching = true
+	stream := tableData.Stream
+	return m, func() tea.Msg {
+		rows, err := stream.Fetch(db.PageSize)
+		return pageMsg{tableData: tableData, rows: rows, err: err}
+	}
+}
+
+func (m Model) appendPage(msg pageMsg) (tea.Model, tea.Cmd) {
+	// Pages of a view that was replaced in the meantime are dropped
+	if msg.tableData != m.tableData {
+		return m, nil
+	}
+	m.fetching = false
+	m.tableData.Append(msg.rows)
+	m = m.handleWindowResize(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+	if msg.err != nil {
+		return m, m.setError(fmt.Sprintf("Loading rows failed: %v", msg.err))
+	}
+	return m, nil
+}
+
+// releaseStream stops loading rows when the open result set would hold up
+// other statements on the connection. The rows read so far stay.
+func (m Model) releaseStream() {
+	tableData := m.tableData
+	if tableData == nil || tableData.Stream == nil || tableData.Connection == nil {
+		return
+	}
+	if tableData.Connection.GetDialect().ReadsBlockStatements() {
+		tableData.Close()
+	}
+}
+
+// rowCount renders how many rows the view has, with a "?" total while rows
+// are still coming in: "1,000 of ? rows".
+func (m Model) rowCount() string {
+	loaded := formatCount(m.numRows())
+	if m.tableData != nil && m.tableData.Stream != nil {
+		return fmt.Sprintf("%s of ? rows", loaded)
+	}
+	return fmt.Sprintf("of %s×%d", loaded, m.numCols())
+}
+
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -14,6 +14,11 @@ const (
 	colorError        = "196"
 	colorKeyHighlight = "205"
 	colorNormal       = "252"
+	colorStaged       = "214"
+	colorAdded        = "42"
+	colorRemoved      = "203"
+	colorChanged      = "221"
+	colorChangedBg    = "58"
 )
 
 var (
@@ -35,8 +40,27 @@ var (
 	borderStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color(colorBorder))
 
+	stagedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(colorStaged)).
+			Bold(true)
+
 	copiedBlinkStyle = lipgloss.NewStyle().
 				Background(lipgloss.Color(colorSelectedBg)).
 				Foreground(lipgloss.Color(colorCopiedBlink)).
 				Bold(true)
+
+	addedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(colorAdded))
+
+	removedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(colorRemoved)).
+			Strikethrough(true)
+
+	changedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(colorChanged))
+
+	changedCellStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color(colorChangedBg)).
+				Foreground(lipgloss.Color(colorChanged)).
+				Bold(true)
 )
@@ -1,9 +1,14 @@
 package table
 
 import (
+	"context"
+	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eduardofuncao/pam/internal/db"
 )
 
 func (m Model) runCommand(input string) (tea.Model, tea.Cmd) {
@@ -15,6 +20,12 @@ func (m Model) runCommand(input string) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if len(m.pending) > 0 {
+		m.commandMode = false
+		m.commandInput.Reset()
+		return m, m.setError(fmt.Sprintf("%d staged changes: commit or discard them first (w)", len(m.pending)))
+	}
+
 	if m.executeCommand == nil {
 		m.commandMode = false
 		m.commandInput.Reset()
@@ -26,6 +37,11 @@ func (m Model) runCommand(input string) (tea.Model, tea.Cmd) {
 		input = "run " + input
 	}
 
+	// Describe the table being browsed when no table is given
+	if (input == "describe" || input == "desc") && m.tableData != nil && m.tableData.TableName != "" {
+		input += " " + m.tableData.TableName
+	}
+
 	// Expand SQL if current table is available
 	if m.tableData != nil && m.tableData.TableName != "" {
 		input = m.expandSQL(input, m.tableData.TableName)
@@ -35,50 +51,127 @@ func (m Model) runCommand(input string) (tea.Model, tea.Cmd) {
 	args := []string{"pam"}
 	args = append(args, strings.Fields(input)...)
 
-	// Execute command via injected executor
-	tableData, err := m.executeCommand(args)
-	if err != nil {
-		m.commandMode = false
-		m.commandInput.Reset()
-		errorMsg := strings.ReplaceAll(err.Error(), "\n", " ")
-		return m, m.setError(errorMsg)
+	m.commandMode = false
+	m.commandInput.Reset()
+	return m.startCommand(args)
+}
+
+// commandDoneMsg carries the result of a command run in the background.
+type commandDoneMsg struct {
+	tableData *db.TableData
+	err       error
+	refreshed bool           // the command returned no view and the original query was re-run
+	exec      *db.ExecResult // what a statement without rows changed
+}
+
+type runTickMsg struct{}
+
+// startCommand runs args through the executor off the Update loop. Until it
+// finishes the footer shows a running state and Ctrl+C or Esc cancels the
+// statement on the server.
+func (m Model) startCommand(args []string) (tea.Model, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.running = true
+	m.cancelled = false
+	m.cancelRun = cancel
+	m.runStarted = time.Now()
+	m.clearStatus()
+	m.releaseStream()
+
+	execute := m.executeCommand
+	originalSQL := m.originalSQL
+	refreshArgs := m.refreshArgs()
+	run := func() tea.Msg {
+		tableData, err := execute(ctx, args)
+		var exec *db.ExecResult
+		if tableData != nil && tableData.Exec != nil {
+			exec, tableData = tableData.Exec, nil
+		}
+		if err != nil || tableData != nil || originalSQL == "" {
+			return commandDoneMsg{tableData: tableData, err: err, exec: exec}
+		}
+
+		// No TableData returned, refresh original query
+		refreshData, err := execute(ctx, refreshArgs)
+		if err != nil {
+			err = fmt.Errorf("failed to refresh: %w", err)
+		}
+		return commandDoneMsg{tableData: refreshData, err: err, refreshed: true, exec: exec}
 	}
+	return m, tea.Batch(run, runTick())
+}
 
-	// If command returned TableData, switch to that view
-	if tableData != nil {
-		m.tableData = tableData
-		m.columnWidths = calculateColumnWidths(tableData)
-		m.selectedRow = 0
-		m.selectedCol = 0
-		m.offsetX = 0
-		m.offsetY = 0
-		m.commandMode = false
-		m.commandInput.Reset()
-		return m, m.setSuccess("View updated")
+// refreshArgs re-runs the original query with the parameter values it was
+// first run with.
+func (m Model) refreshArgs() []string {
+	args := []string{"pam", "run", m.originalSQL}
+	names := make([]string, 0, len(m.originalParams))
+	for name := range m.originalParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, fmt.Sprintf("--%s=%s", name, m.originalParams[name]))
 	}
+	return args
+}
 
-	// No TableData returned, refresh original query
-	if m.originalSQL != "" {
-		refreshArgs := []string{"pam", "run", m.originalSQL}
-		refreshData, refreshErr := m.executeCommand(refreshArgs)
-		if refreshErr != nil {
-			m.commandMode = false
-			m.commandInput.Reset()
-			return m, m.setError("Failed to refresh: " + refreshErr.Error())
-		}
-		if refreshData != nil {
-			m.tableData = refreshData
-			m.columnWidths = calculateColumnWidths(refreshData)
-			m.selectedRow = 0
-			m.selectedCol = 0
-			m.offsetX = 0
-			m.offsetY = 0
+func runTick() tea.Cmd {
+	return tea.Tick(100*time.Millisecond, func(time.Time) tea.Msg {
+		return runTickMsg{}
+	})
+}
+
+func (m Model) cancelCommand() (tea.Model, tea.Cmd) {
+	if !m.cancelled {
+		m.cancelled = true
+		m.cancelRun()
+	}
+	return m, nil
+}
+
+func (m Model) finishCommand(msg commandDoneMsg) (tea.Model, tea.Cmd) {
+	m.running = false
+	cancelRun := m.cancelRun
+	m.cancelRun = nil
+
+	if m.cancelled {
+		m.cancelled = false
+		cancelRun()
+		if msg.tableData != nil {
+			msg.tableData.Close()
 		}
+		return m, m.setError("Query cancelled")
+	}
+	if msg.err != nil {
+		cancelRun()
+		errorMsg := strings.ReplaceAll(msg.err.Error(), "\n", " ")
+		return m, m.setError(errorMsg)
 	}
 
-	m.commandMode = false
-	m.commandInput.Reset()
-	return m, m.setSuccess("Command executed")
+	// The run context is left alone on success: a streamed result still
+	// reads rows under it and releases it once they are done
+	if msg.tableData != nil {
+		m.setTableData(msg.tableData)
+		m.elapsed = time.Since(m.runStarted)
+	}
+	if msg.exec != nil {
+		return m, m.setSuccess(execStatus(msg.exec))
+	}
+	if msg.refreshed {
+		return m, m.setSuccess("Command executed")
+	}
+	return m, m.setSuccess("View updated")
+}
+
+// execStatus reports a statement without rows, e.g.
+// "3 rows affected, last insert id 42 (0.02s)".
+func execStatus(result *db.ExecResult) string {
+	summary := result.Summary()
+	if summary == "" {
+		summary = "Command executed"
+	}
+	return fmt.Sprintf("%s (%.2fs)", summary, result.Elapsed.Seconds())
 }
 
 func (m Model) expandSQL(input, tableName string) string {
@@ -133,7 +226,7 @@ func (m Model) expandSQL(input, tableName string) string {
 }
 
 func looksLikeSQL(input string) bool {
-	sqlKeywords := []string{"SELECT", "INSERT", "UPDATE", "DELETE", "WITH", "EXPLAIN", "DESCRIBE", "SHOW", "PRAGMA"}
+	sqlKeywords := []string{"SELECT", "INSERT", "UPDATE", "DELETE", "WITH", "EXPLAIN", "SHOW", "PRAGMA"}
 	upper := strings.ToUpper(strings.TrimSpace(input))
 
 	for _, keyword := range sqlKeywords {
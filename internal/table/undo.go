@@ -0,0 +1,118 @@
+package table
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eduardofuncao/pam/internal/db"
+)
+
+// cellEdit is an applied cell change. before holds the exact raw value the
+// database had, so undo can restore it (NULL included).
+type cellEdit struct {
+	row    int
+	col    int
+	before db.Cell
+	after  db.Cell
+}
+
+func (m *Model) recordEdit(edit cellEdit) {
+	m.undoStack = append(m.undoStack, edit)
+	m.redoStack = nil
+}
+
+func (m Model) undo() (tea.Model, tea.Cmd) {
+	if m.staging && len(m.pending) > 0 {
+		return m.unstageLast()
+	}
+	if len(m.undoStack) == 0 {
+		return m, m.setError("Nothing to undo")
+	}
+
+	edit := m.undoStack[len(m.undoStack)-1]
+	if err := m.restoreCell(edit.row, edit.col, edit.before); err != nil {
+		return m, m.setError(fmt.Sprintf("Undo failed: %v", err))
+	}
+	m.undoStack = m.undoStack[:len(m.undoStack)-1]
+	m.redoStack = append(m.redoStack, edit)
+	m.moveTo(edit.row, edit.col)
+	return m, m.setSuccess(fmt.Sprintf("Undone (%d more)", len(m.undoStack)))
+}
+
+func (m Model) redo() (tea.Model, tea.Cmd) {
+	if m.staging && len(m.unstaged) > 0 {
+		return m.restageLast()
+	}
+	if len(m.redoStack) == 0 {
+		return m, m.setError("Nothing to redo")
+	}
+
+	edit := m.redoStack[len(m.redoStack)-1]
+	if err := m.restoreCell(edit.row, edit.col, edit.after); err != nil {
+		return m, m.setError(fmt.Sprintf("Redo failed: %v", err))
+	}
+	m.redoStack = m.redoStack[:len(m.redoStack)-1]
+	m.undoStack = append(m.undoStack, edit)
+	m.moveTo(edit.row, edit.col)
+	return m, m.setSuccess(fmt.Sprintf("Redone (%d more)", len(m.redoStack)))
+}
+
+// restoreCell issues a compensating UPDATE that writes target's raw value
+// back, then puts target in the grid.
+func (m *Model) restoreCell(row, col int, target db.Cell) error {
+	if m.getCell(row, col) == nil {
+		return fmt.Errorf("row is no longer in this view")
+	}
+	m.loadSchema()
+	query, args, err := m.buildUpdateQuery(&m.tableData.Rows[row][col], target.RawValue)
+	if err != nil {
+		return err
+	}
+	if err := m.execSingleRow(query, args); err != nil {
+		return err
+	}
+	m.tableData.Rows[row][col] = target
+	return nil
+}
+
+// unstageLast drops the newest staged change; redo stages it again.
+func (m Model) unstageLast() (tea.Model, tea.Cmd) {
+	change := m.pending[len(m.pending)-1]
+	m.pending = m.pending[:len(m.pending)-1]
+	switch change.kind {
+	case changeUpdate:
+		change.newCell = m.tableData.Rows[change.row][change.col]
+		m.tableData.Rows[change.row][change.col] = change.oldCell
+	case changeInsert:
+		m.removeGridRow(change.row)
+	}
+	m.unstaged = append(m.unstaged, change)
+	m.moveTo(change.row, change.col)
+	return m, m.setSuccess(fmt.Sprintf("Unstaged (%d pending)", len(m.pending)))
+}
+
+func (m Model) restageLast() (tea.Model, tea.Cmd) {
+	change := m.unstaged[len(m.unstaged)-1]
+	m.unstaged = m.unstaged[:len(m.unstaged)-1]
+	switch change.kind {
+	case changeUpdate:
+		m.tableData.Rows[change.row][change.col] = change.newCell
+	case changeInsert:
+		m.insertGridRow(change.row, change.cells)
+	}
+	m.pending = append(m.pending, change)
+	m.moveTo(change.row, change.col)
+	return m, m.setSuccess(fmt.Sprintf("Restaged (%d pending)", len(m.pending)))
+}
+
+// moveTo puts the cursor on a cell and scrolls it into view.
+func (m *Model) moveTo(row, col int) {
+	m.selectedRow = row
+	m.selectedCol = col
+	if row < m.offsetY || row >= m.offsetY+m.visibleRows {
+		m.offsetY = max(row-m.visibleRows/2, 0)
+	}
+	if col < m.offsetX || col >= m.offsetX+m.visibleCols {
+		m.offsetX = col
+	}
+}
@@ -16,19 +16,34 @@ const (
 	tempFilePattern    = "pam-cell-*.txt"
 	msgUpdateSuccess   = "Updated successfully"
 	msgUpdateFailedFmt = "Update failed: %v"
-	dbTypePostgres     = "postgres"
-	dbTypeOracle       = "oracle"
+	msgStagedFmt       = "Staged (%d pending, w to review)"
 )
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	m, fetch := model.(Model).fetchMoreRows()
+	return m, tea.Batch(cmd, fetch)
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		return m.handleKeyPress(msg)
+	case pageMsg:
+		return m.appendPage(msg)
 	case blinkMsg:
 		m.blinkCopiedCell = false
 	case clearStatusMsg:
-		m.clearStatus()
+		if !m.running {
+			m.clearStatus()
+		}
 		return m, nil
+	case commandDoneMsg:
+		return m.finishCommand(msg)
+	case runTickMsg:
+		if m.running {
+			return m, runTick()
+		}
 	case tea.WindowSizeMsg:
 		return m.handleWindowResize(msg), nil
 	}
@@ -37,6 +52,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// While a command runs only cancelling is possible
+	if m.running {
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEscape:
+			return m.cancelCommand()
+		}
+		return m, nil
+	}
+
 	// Handle confirm mode keys
 	if m.confirmMode {
 		switch msg.Type {
@@ -51,6 +75,14 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if m.reviewMode {
+		return m.handleReviewKey(msg)
+	}
+
+	if m.insertForm != nil {
+		return m.handleInsertFormKey(msg)
+	}
+
 	// Handle command mode keys
 	if m.commandMode {
 		switch msg.Type {
@@ -67,9 +99,24 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if m.pickKeys != nil {
+		if model, cmd, handled := m.handlePickKey(msg); handled {
+			return model, cmd
+		}
+	}
+
 	// Normal mode keys
+	if msg.String() != "q" {
+		m.quitArmed = false
+	}
 	switch msg.String() {
-	case "ctrl+c", "q":
+	case "ctrl+c":
+		return m, tea.Quit
+	case "q":
+		if len(m.pending) > 0 && !m.quitArmed {
+			m.quitArmed = true
+			return m, m.setError(fmt.Sprintf("%d staged changes will be lost, press q again to quit", len(m.pending)))
+		}
 		return m, tea.Quit
 
 	case ";":
@@ -113,8 +160,35 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "e":
 		return m.editCell()
 
+	case "E":
+		return m.bulkEdit(',')
+
+	case "T":
+		return m.bulkEdit('\t')
+
 	case "d":
 		return m.enterDeleteConfirm()
+
+	case "D":
+		return m.enterRowDeleteConfirm()
+
+	case "o":
+		return m.openInsertForm(true)
+
+	case "O":
+		return m.openInsertForm(false)
+
+	case "s":
+		return m.toggleStaging()
+
+	case "w":
+		return m.openReview()
+
+	case "u":
+		return m.undo()
+
+	case "ctrl+r":
+		return m.redo()
 	}
 
 	return m, nil
@@ -198,17 +272,7 @@ func (m Model) editCell() (tea.Model, tea.Cmd) {
 	}
 	tmpfile.Close()
 
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = defaultEditor
-	}
-
-	cmd := exec.Command(editor, tmpfilePath)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := runEditor(tmpfilePath); err != nil {
 		log.Printf("Error running editor: %v", err)
 		return m, nil
 	}
@@ -231,40 +295,66 @@ func (m Model) editCell() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	updateSQL, args := m.buildUpdateQuery(cell, newValueStr)
+	// An empty editor buffer sets the cell to NULL
+	var value any
+	if newValueStr != "" {
+		value = newValueStr
+	}
 
-	_, err = m.tableData.Connection.GetDB().Exec(updateSQL, args...)
-	if err != nil {
+	if err := m.writeCell(cell.RowIndex, cell.ColumnIndex, value); err != nil {
 		return m, m.setError(fmt.Sprintf(msgUpdateFailedFmt, err))
 	}
-
-	if newValueStr == "" {
-		m.tableData.Rows[cell.RowIndex][cell.ColumnIndex].Value = "NULL"
-		m.tableData.Rows[cell.RowIndex][cell.ColumnIndex].RawValue = nil
-	} else {
-		m.tableData.Rows[cell.RowIndex][cell.ColumnIndex].Value = newValueStr
-		m.tableData.Rows[cell.RowIndex][cell.ColumnIndex].RawValue = newValueStr
+	if m.staging {
+		return m, m.setSuccess(fmt.Sprintf(msgStagedFmt, len(m.pending)))
 	}
 
 	return m, m.setSuccess(msgUpdateSuccess)
 }
 
-// buildRowFilter builds a WHERE clause using all columns in the row (except excludeCol if >= 0).
-// Returns the WHERE clause string and args, starting parameters at paramStart.
+// runEditor opens path in $EDITOR (vi by default) and waits for it to exit.
+func runEditor(path string) error {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = defaultEditor
+	}
+
+	cmd := exec.Command(editor, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// buildRowFilter builds a WHERE clause that targets a single row. When the
+// table has a primary or unique key present in the result, only the key
+// columns are used; otherwise every column except excludeCol (if >= 0) is
+// matched. Returns the WHERE clause string and args, starting parameters at
+// paramStart.
 func (m Model) buildRowFilter(rowIndex int, excludeCol int, paramStart int) (string, []any) {
 	var conditions []string
 	var args []any
 	paramIndex := paramStart
-	dbType := m.tableData.Connection.GetDbType()
+	dialect := m.tableData.Connection.GetDialect()
+
+	row := m.tableData.Rows[rowIndex]
+	cells := []db.Cell(row)
+	if key := m.rowKey(rowIndex); key != nil {
+		cells = make([]db.Cell, len(key))
+		for i, idx := range key {
+			cells[i] = row[idx]
+		}
+		excludeCol = -1
+	}
 
-	for _, c := range m.tableData.Rows[rowIndex] {
+	for _, c := range cells {
 		if c.ColumnIndex == excludeCol {
 			continue
 		}
+		column := dialect.QuoteIdentifier(m.tableData.Columns[c.ColumnIndex])
 		if c.RawValue == nil {
-			conditions = append(conditions, fmt.Sprintf("%s IS NULL", c.ColumnName))
+			conditions = append(conditions, fmt.Sprintf("%s IS NULL", column))
 		} else {
-			conditions = append(conditions, fmt.Sprintf("%s = %s", c.ColumnName, m.placeholder(dbType, paramIndex)))
+			conditions = append(conditions, fmt.Sprintf("%s = %s", column, dialect.Placeholder(paramIndex)))
 			args = append(args, c.RawValue)
 			paramIndex++
 		}
@@ -273,39 +363,35 @@ func (m Model) buildRowFilter(rowIndex int, excludeCol int, paramStart int) (str
 	return strings.Join(conditions, " AND "), args
 }
 
-func (m Model) buildUpdateQuery(cell *db.Cell, newValue string) (string, []any) {
-	dbType := m.tableData.Connection.GetDbType()
+// buildUpdateQuery sets the cell's column to newValue, or to NULL when
+// newValue is nil.
+func (m Model) buildUpdateQuery(cell *db.Cell, newValue any) (string, []any, error) {
+	dialect := m.tableData.Connection.GetDialect()
 
 	var setClause string
 	var setArgs []any
 	paramIndex := 1
+	column := dialect.QuoteIdentifier(m.tableData.Columns[cell.ColumnIndex])
 
-	if newValue == "" {
-		setClause = fmt.Sprintf("%s = NULL", cell.ColumnName)
+	if newValue == nil {
+		setClause = fmt.Sprintf("%s = NULL", column)
 	} else {
 		setArgs = append(setArgs, newValue)
-		setClause = fmt.Sprintf("%s = %s", cell.ColumnName, m.placeholder(dbType, paramIndex))
+		setClause = fmt.Sprintf("%s = %s", column, dialect.Placeholder(paramIndex))
 		paramIndex++
 	}
 
+	tableName, err := db.QuoteName(dialect, m.tableData.TableName)
+	if err != nil {
+		return "", nil, err
+	}
+
 	whereClause, whereArgs := m.buildRowFilter(cell.RowIndex, cell.ColumnIndex, paramIndex)
 	args := append(setArgs, whereArgs...)
 
-	sql := fmt.Sprintf("UPDATE %s SET %s WHERE %s",
-		m.tableData.TableName, setClause, whereClause)
-
-	return sql, args
-}
+	sql := fmt.Sprintf("UPDATE %s SET %s WHERE %s", tableName, setClause, whereClause)
 
-func (m Model) placeholder(dbType string, index int) string {
-	switch dbType {
-	case dbTypePostgres:
-		return fmt.Sprintf("$%d", index)
-	case dbTypeOracle:
-		return fmt.Sprintf(":%d", index)
-	default:
-		return "?"
-	}
+	return sql, args, nil
 }
 
 func (m Model) enterDeleteConfirm() (tea.Model, tea.Cmd) {
@@ -318,6 +404,7 @@ func (m Model) enterDeleteConfirm() (tea.Model, tea.Cmd) {
 	}
 	m.confirmMode = true
 	m.confirmAction = "clear_cell"
+	m.confirmPrompt = "Clear cell to NULL?"
 	return m, nil
 }
 
@@ -326,8 +413,11 @@ func (m Model) executeConfirmAction() (tea.Model, tea.Cmd) {
 	action := m.confirmAction
 	m.confirmAction = ""
 
-	if action == "clear_cell" {
+	switch action {
+	case "clear_cell":
 		return m.clearCell()
+	case "delete_rows":
+		return m.deleteRows()
 	}
 	return m, nil
 }
@@ -338,17 +428,12 @@ func (m Model) clearCell() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	// Reuse buildUpdateQuery with empty string (which sets to NULL)
-	updateSQL, args := m.buildUpdateQuery(cell, "")
-
-	_, err := m.tableData.Connection.GetDB().Exec(updateSQL, args...)
-	if err != nil {
+	if err := m.writeCell(cell.RowIndex, cell.ColumnIndex, nil); err != nil {
 		return m, m.setError(fmt.Sprintf("Clear failed: %v", err))
 	}
-
-	// Update local data
-	m.tableData.Rows[cell.RowIndex][cell.ColumnIndex].Value = "NULL"
-	m.tableData.Rows[cell.RowIndex][cell.ColumnIndex].RawValue = nil
+	if m.staging {
+		return m, m.setSuccess(fmt.Sprintf(msgStagedFmt, len(m.pending)))
+	}
 
 	return m, m.setSuccess("Cell cleared")
 }
@@ -3,6 +3,7 @@ package table
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/mattn/go-runewidth"
@@ -15,10 +16,18 @@ const (
 	truncationEllipsis = "…"
 )
 
+var runFrames = []string{"▉", "▊", "▋", "▌", "▍", "▎", "▏", "▎", "▍", "▌", "▋", "▊"}
+
 func (m Model) View() string {
 	if m.width == 0 {
 		return msgLoading
 	}
+	if m.reviewMode {
+		return m.renderReview()
+	}
+	if m.insertForm != nil {
+		return m.renderInsertForm()
+	}
 
 	var b strings.Builder
 
@@ -89,17 +98,29 @@ func (m Model) renderFooter() string {
 	colType := "?"
 	cellValue := ""
 	if cell != nil {
-		colType = cell.ColumnType
+		colType = m.tableData.ColumnType(cell.ColumnIndex)
 		cellValue = cell.Value
 	}
 
 	// First line: confirm prompt OR command prompt OR column type + (status message OR cell content)
 	var firstLine string
-	if m.confirmMode {
+	if m.running {
+		promptStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorStaged)).Bold(true)
+		hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+		state := "Running"
+		if m.cancelled {
+			state = "Cancelling"
+		}
+		elapsed := time.Since(m.runStarted)
+		frame := runFrames[int(elapsed/(100*time.Millisecond))%len(runFrames)]
+		firstLine = fmt.Sprintf("\n%s %s",
+			promptStyle.Render(fmt.Sprintf("%s %s %.1fs", frame, state, elapsed.Seconds())),
+			hintStyle.Render("[Ctrl+C/Esc] cancel"))
+	} else if m.confirmMode {
 		promptStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
 		hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 		firstLine = fmt.Sprintf("\n%s %s",
-			promptStyle.Render("Clear cell to NULL?"),
+			promptStyle.Render(m.confirmPrompt),
 			hintStyle.Render("[Enter] confirm  [Esc] cancel"))
 	} else if m.commandMode {
 		firstLine = fmt.Sprintf("\n%s", m.commandInput.View())
@@ -119,13 +140,30 @@ func (m Model) renderFooter() string {
 	whiteStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
 
 	positionStr := fmt.Sprintf("[%d,%d]", m.selectedRow+1, m.selectedCol+1)
-	sizeStr := fmt.Sprintf("of %d×%d", m.numRows(), m.numCols())
+	sizeStr := whiteStyle.Render(m.rowCount())
+	if m.tableData != nil && m.tableData.Truncated {
+		sizeStr += lipgloss.NewStyle().Foreground(lipgloss.Color(colorStaged)).Render(" (truncated)")
+	}
 
-	secondLine := fmt.Sprintf("%s %s | %s  %s  %s  %s  %s  %s",
+	actions := []string{edit, del, yank, cmd, quit, nav}
+	if m.pickKeys != nil {
+		actions = nil
+		for _, pk := range m.pickKeys {
+			actions = append(actions, keyStyle.Render(pk.Key)+" "+normalStyle.Render(pk.Hint))
+		}
+		actions = append(actions, yank, quit, nav)
+	}
+
+	secondLine := fmt.Sprintf("%s %s | %s",
 		highlightStyle.Render(positionStr),
-		whiteStyle.Render(sizeStr),
-		edit, del, yank, cmd, quit, nav,
+		sizeStr,
+		strings.Join(actions, "  "),
 	)
+	if m.staging {
+		stagingStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorStaged)).Bold(true)
+		review := keyStyle.Render("w") + normalStyle.Render(" review")
+		secondLine += fmt.Sprintf(" | %s  %s", stagingStyle.Render(fmt.Sprintf("STAGING %d", len(m.pending))), review)
+	}
 
 	return firstLine + "\n" + secondLine
 }
@@ -138,6 +176,14 @@ func (m Model) getCellStyle(row, col int) lipgloss.Style {
 		return selectedStyle
 	}
 
+	if m.isCellPending(row, col) {
+		return stagedStyle
+	}
+
+	if style, ok := m.markStyle(row, col); ok {
+		return style
+	}
+
 	cell := m.getCell(row, col)
 	if cell != nil && cell.Value == "NULL" {
 		return nullStyle